@@ -0,0 +1,85 @@
+package emulator
+
+// The parts of the machine a custom opcode handler can read and change
+type Accessor interface {
+	Opcode() uint16
+	Register(x byte) byte
+	SetRegister(x byte, value byte)
+	IndexRegister() uint16
+	SetIndexRegister(addr uint16)
+	ProgramCounter() uint16
+	SetProgramCounter(addr uint16) error
+	ReadMemory(addr uint16) byte
+	WriteMemory(addr uint16, value byte)
+}
+
+// A user supplied handler for opcodes where (opcode & mask) == match
+type opcodeHandler struct {
+	match uint16
+	mask  uint16
+	fn    func(c Accessor)
+}
+
+/*
+Registers fn to run in place of the built-in decode for any opcode where (opcode & mask) == match, for prototyping
+instruction-set extensions without forking. Handlers are tried in the order they were registered.
+*/
+func (c8 *chip8) RegisterOpcodeHandler(match uint16, mask uint16, fn func(c Accessor)) {
+	c8.opcodeHandlers = append(c8.opcodeHandlers, opcodeHandler{match: match, mask: mask, fn: fn})
+}
+
+// Runs the first registered handler matching the current opcode, reporting whether there was one
+func (c8 *chip8) runOpcodeHandler() bool {
+	for _, h := range c8.opcodeHandlers {
+		if c8.opcode&h.mask == h.match {
+			h.fn(c8)
+			return true
+		}
+	}
+
+	return false
+}
+
+// Returns the opcode currently being executed
+func (c8 *chip8) Opcode() uint16 {
+	return c8.opcode
+}
+
+// Returns the value of register Vx
+func (c8 *chip8) Register(x byte) byte {
+	return c8.registers[x&0xF]
+}
+
+// Sets register Vx
+func (c8 *chip8) SetRegister(x byte, value byte) {
+	c8.registers[x&0xF] = value
+}
+
+// Returns the index register I
+func (c8 *chip8) IndexRegister() uint16 {
+	return c8.indexRegister
+}
+
+// Sets the index register I
+func (c8 *chip8) SetIndexRegister(addr uint16) {
+	c8.indexRegister = addr
+}
+
+// Returns the address of the next instruction to execute
+func (c8 *chip8) ProgramCounter() uint16 {
+	return c8.programCounter
+}
+
+// Returns the byte at addr, or 0 if addr is past the end of memory
+func (c8 *chip8) ReadMemory(addr uint16) byte {
+	if int(addr) >= len(c8.memory) {
+		return 0
+	}
+
+	return c8.memory[addr]
+}
+
+// Writes a byte to addr
+func (c8 *chip8) WriteMemory(addr uint16, value byte) {
+	c8.writeMemory(addr, value)
+}
@@ -0,0 +1,65 @@
+package emulator
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRegisterOpcodeHandler(t *testing.T) {
+	// 8xy8 is unused, so stands in for a new instruction: Vx = Vx * Vy
+	multiply := func(c Accessor) {
+		x, y := byte(c.Opcode()>>8), byte(c.Opcode()>>4)
+		c.SetRegister(x, c.Register(x)*c.Register(y))
+	}
+
+	tests := []struct {
+		name     string
+		match    uint16
+		mask     uint16
+		fn       func(c Accessor)
+		v0       byte
+		pc       uint16
+		unknown  bool
+		handlers int
+	}{
+		{"unused opcode runs the handler", 0x8008, 0xF00F, multiply, 12, 0x0206, false, 1},
+		{"unused opcode without a handler", 0x5001, 0xF00F, multiply, 3, 0x0206, true, 0},
+		{"built-in opcode overridden", 0x6000, 0xF000, func(c Accessor) { c.SetRegister(0, 0x99) }, 0x99, 0x0206, true, 2},
+		{"handler moves the program counter", 0x8008, 0xF00F, func(c Accessor) { c.SetProgramCounter(0x0200) }, 3, 0x0200, false, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// LD V0, 3; LD V1, 4; 8018
+			c8 := newTestCore(t, 0x60, 0x03, 0x61, 0x04, 0x80, 0x18)
+			calls := 0
+			c8.RegisterOpcodeHandler(tt.match, tt.mask, func(c Accessor) {
+				calls++
+				tt.fn(c)
+			})
+
+			// Only the first matching handler runs
+			c8.RegisterOpcodeHandler(tt.match, tt.mask, func(c Accessor) {
+				t.Error("second matching handler ran")
+			})
+
+			stepN(t, c8, 2)
+			_, err := c8.Step()
+			if errors.Is(err, ErrUnknownOpcode) != tt.unknown {
+				t.Errorf("8018 returned %v, want an unknown opcode error %t", err, tt.unknown)
+			}
+
+			if c8.Register(0) != tt.v0 {
+				t.Errorf("V0 = %d, want %d", c8.Register(0), tt.v0)
+			}
+
+			if c8.ProgramCounter() != tt.pc {
+				t.Errorf("program counter = 0x%04X, want 0x%04X", c8.ProgramCounter(), tt.pc)
+			}
+
+			if calls != tt.handlers {
+				t.Errorf("handler ran %d times, want %d", calls, tt.handlers)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package emulator
+
+import (
+	"math"
+	"time"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// Sample rate requested from the audio device
+const AUDIO_SAMPLE_RATE = 44100
+
+// Default beep: a 440Hz square wave at a quarter of full volume
+const DEFAULT_TONE_FREQUENCY = 440.0
+const DEFAULT_TONE_VOLUME = 0.25
+
+// How much audio is kept queued while beeping; short enough that the beep stops promptly when the timer runs out
+const AUDIO_QUEUE_AHEAD = TIMER_INTERVAL * 2
+
+/*
+Opens the default audio device for 8-bit mono playback and starts it.
+Nothing is queued until the sound timer is set, so the device plays silence until then.
+*/
+func (c8 *chip8) openAudio() error {
+	desired := sdl.AudioSpec{
+		Freq:     AUDIO_SAMPLE_RATE,
+		Format:   sdl.AUDIO_S8,
+		Channels: 1,
+		Samples:  512,
+	}
+	var obtained sdl.AudioSpec
+
+	device, err := sdl.OpenAudioDevice("", false, &desired, &obtained, 0)
+	if err != nil {
+		return err
+	}
+
+	c8.audioDevice = device
+	c8.audioSampleRate = int(obtained.Freq)
+	sdl.PauseAudioDevice(device, false)
+
+	return nil
+}
+
+// Closes the audio device if one was opened
+func (c8 *chip8) closeAudio() {
+	if c8.audioDevice == 0 {
+		return
+	}
+
+	sdl.CloseAudioDevice(c8.audioDevice)
+	c8.audioDevice = 0
+}
+
+// Sets the beep's frequency in Hz and its volume from 0 (silent) to 1 (full scale)
+func (c8 *chip8) SetTone(frequency float64, volume float64) {
+	if frequency > 0 {
+		c8.toneFrequency = frequency
+	}
+
+	c8.toneVolume = min(max(volume, 0), 1)
+}
+
+/*
+Keeps the tone queued for as long as the sound timer is running and drops whatever is left once it stops.
+The wave's phase carries over between calls so back-to-back chunks join up without clicks.
+*/
+func (c8 *chip8) updateAudio() {
+	if c8.audioDevice == 0 {
+		return
+	}
+
+	if !c8.IsBeeping() {
+		// Whatever is still queued is thrown away rather than played
+		c8.audioSamples -= min(uint64(sdl.GetQueuedAudioSize(c8.audioDevice)), c8.audioSamples)
+		sdl.ClearQueuedAudio(c8.audioDevice)
+		return
+	}
+
+	ahead := int(float64(c8.audioSampleRate) * AUDIO_QUEUE_AHEAD.Seconds())
+	queued := int(sdl.GetQueuedAudioSize(c8.audioDevice))
+	if queued >= ahead {
+		return
+	}
+
+	samples := make([]byte, ahead-queued)
+	c8.fillTone(samples, c8.audioSampleRate, &c8.tonePhase)
+	c8.audioSamples += uint64(len(samples))
+
+	sdl.QueueAudio(c8.audioDevice, samples)
+}
+
+// Fills samples with the beep's square wave as signed 8-bit values, advancing phase so the next call joins up
+func (c8 *chip8) fillTone(samples []byte, sampleRate int, phase *float64) {
+	amplitude := int8(math.Round(c8.toneVolume * math.MaxInt8))
+	step := c8.toneFrequency / float64(sampleRate)
+
+	for i := range samples {
+		sample := amplitude
+		if *phase >= 0.5 {
+			sample = -amplitude
+		}
+
+		samples[i] = byte(sample)
+		*phase = math.Mod(*phase+step, 1)
+	}
+}
+
+/*
+Returns how far the beep played on the audio device has drifted from the sound timer: the audio played so far minus
+the time the sound timer has spent running, counted in 60Hz ticks. Positive means audio ran ahead of the timer,
+negative that it fell behind, for tuning AUDIO_QUEUE_AHEAD. Without an audio device nothing plays, so it falls behind
+by the whole time the timer ran.
+*/
+func (c8 *chip8) AudioDrift() time.Duration {
+	played := c8.audioSamples
+	if c8.audioDevice != 0 {
+		played -= min(uint64(sdl.GetQueuedAudioSize(c8.audioDevice)), played)
+	}
+
+	var audio time.Duration
+	if c8.audioSampleRate > 0 {
+		audio = time.Duration(played) * time.Second / time.Duration(c8.audioSampleRate)
+	}
+
+	return audio - time.Duration(c8.beepTicks)*time.Second/60
+}
+
+// Starts measuring AudioDrift afresh from now
+func (c8 *chip8) ResetAudioDrift() {
+	c8.beepTicks = 0
+	c8.audioSamples = 0
+
+	if c8.audioDevice != 0 {
+		c8.audioSamples = uint64(sdl.GetQueuedAudioSize(c8.audioDevice))
+	}
+}
@@ -0,0 +1,123 @@
+package emulator
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAudioDrift(t *testing.T) {
+	tests := []struct {
+		name    string
+		ticks   int
+		samples int
+		want    time.Duration
+	}{
+		{"in sync", 6, 4410, 0},
+		{"audio behind", 6, 2205, -50 * time.Millisecond},
+		{"audio ahead", 3, 4410, 50 * time.Millisecond},
+		{"nothing played", 60, 0, -time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c8 := newTestCore(t)
+			c8.audioSampleRate = AUDIO_SAMPLE_RATE
+			c8.soundTimer = 0xFF
+
+			for range tt.ticks {
+				c8.tickTimers()
+			}
+
+			// Stands in for what updateAudio queues on a device
+			c8.fillTone(make([]byte, tt.samples), c8.audioSampleRate, &c8.tonePhase)
+			c8.audioSamples += uint64(tt.samples)
+
+			if got := c8.AudioDrift(); got != tt.want {
+				t.Errorf("drift = %v, want %v", got, tt.want)
+			}
+
+			c8.ResetAudioDrift()
+			if got := c8.AudioDrift(); got != 0 {
+				t.Errorf("drift = %v after reset, want 0", got)
+			}
+		})
+	}
+}
+
+func TestAudioDriftOnlyCountsBeeping(t *testing.T) {
+	c8 := newTestCore(t)
+	c8.audioSampleRate = AUDIO_SAMPLE_RATE
+	c8.soundTimer = 2
+
+	for range 10 {
+		c8.tickTimers()
+	}
+
+	if want := -2 * time.Second / 60; c8.AudioDrift() != want {
+		t.Errorf("drift = %v, want %v for the two ticks the timer ran", c8.AudioDrift(), want)
+	}
+}
+
+func TestIsBeeping(t *testing.T) {
+	// LD V0, 3; LD ST, V0; then JP 0x204 forever
+	c8 := newTestCore(t, 0x60, 0x03, 0xF0, 0x18, 0x12, 0x04)
+
+	if c8.IsBeeping() {
+		t.Fatal("beeping before the sound timer was set")
+	}
+
+	stepN(t, c8, 2)
+	for tick := range 3 {
+		if !c8.IsBeeping() {
+			t.Fatalf("not beeping %d ticks after setting the sound timer to 3", tick)
+		}
+		c8.tickTimers()
+	}
+
+	if c8.IsBeeping() {
+		t.Error("still beeping once the sound timer ran out")
+	}
+}
+
+func TestFillTone(t *testing.T) {
+	tests := []struct {
+		name       string
+		frequency  float64
+		volume     float64
+		sampleRate int
+		high       int8
+		period     int
+	}{
+		{"default", DEFAULT_TONE_FREQUENCY, DEFAULT_TONE_VOLUME, 44000, 32, 100},
+		{"full volume", 1000, 1, 8000, 127, 8},
+		{"louder than full", 1000, 3, 8000, 127, 8},
+		{"silent", 1000, 0, 8000, 0, 8},
+		{"frequency ignored when not positive", 0, 0.5, 44000, 64, 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c8 := newTestCore(t)
+			c8.SetTone(DEFAULT_TONE_FREQUENCY, DEFAULT_TONE_VOLUME)
+			c8.SetTone(tt.frequency, tt.volume)
+
+			// Filled in two chunks, which must join up into one continuous wave
+			samples := make([]byte, 3*tt.period)
+			phase := 0.0
+			c8.fillTone(samples[:tt.period+3], tt.sampleRate, &phase)
+			c8.fillTone(samples[tt.period+3:], tt.sampleRate, &phase)
+
+			// High for the first half of every period and low for the second
+			for k, s := range samples {
+				want := tt.high
+				if k%tt.period >= tt.period/2 {
+					want = -tt.high
+				}
+
+				if int8(s) != want {
+					t.Fatalf("sample %d = %d, want %d", k, int8(s), want)
+				}
+			}
+		})
+	}
+}
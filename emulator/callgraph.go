@@ -0,0 +1,68 @@
+package emulator
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// A call from the subroutine at caller to the subroutine at callee
+type callEdge struct {
+	caller uint16
+	callee uint16
+}
+
+// Records a 2nnn call from whichever subroutine is currently running; the ROM entry point is the root
+func (c8 *chip8) traceCall(callee uint16) {
+	caller := uint16(START_ADDRESS)
+	if len(c8.callTrace) > 0 {
+		caller = c8.callTrace[len(c8.callTrace)-1]
+	}
+
+	c8.callCounts[callEdge{caller: caller, callee: callee}]++
+	c8.callTrace = append(c8.callTrace, callee)
+}
+
+// Records a 00EE returning from the current subroutine
+func (c8 *chip8) traceReturn() {
+	if len(c8.callTrace) > 0 {
+		c8.callTrace = c8.callTrace[:len(c8.callTrace)-1]
+	}
+}
+
+/*
+Returns a tree of the subroutines called so far, starting from the ROM entry point, with how many times each call
+was made. A subroutine reached again further down its own branch (recursion) is marked rather than expanded.
+*/
+func (c8 *chip8) CallGraph() string {
+	children := make(map[uint16][]uint16)
+	for edge := range c8.callCounts {
+		children[edge.caller] = append(children[edge.caller], edge.callee)
+	}
+
+	var sb strings.Builder
+	var walk func(addr uint16, depth int, path []uint16)
+
+	walk = func(addr uint16, depth int, path []uint16) {
+		callees := children[addr]
+		slices.Sort(callees)
+
+		for _, callee := range callees {
+			indent := strings.Repeat("  ", depth)
+			count := c8.callCounts[callEdge{caller: addr, callee: callee}]
+
+			if slices.Contains(path, callee) {
+				fmt.Fprintf(&sb, "%s0x%04X (x%d, recursive)\n", indent, callee, count)
+				continue
+			}
+
+			fmt.Fprintf(&sb, "%s0x%04X (x%d)\n", indent, callee, count)
+			walk(callee, depth+1, append(path, callee))
+		}
+	}
+
+	fmt.Fprintf(&sb, "0x%04X\n", START_ADDRESS)
+	walk(uint16(START_ADDRESS), 1, []uint16{uint16(START_ADDRESS)})
+
+	return sb.String()
+}
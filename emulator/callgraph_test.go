@@ -0,0 +1,45 @@
+package emulator
+
+import "testing"
+
+func TestCallGraph(t *testing.T) {
+	tests := []struct {
+		name string
+		rom  []byte
+		want string
+	}{
+		{"no calls", []byte{0x12, 0x00}, "0x0200\n"},
+		{
+			// CALL 0x20A twice, which calls 0x210, then CALL 0x210 directly
+			"nested",
+			[]byte{0x22, 0x0A, 0x22, 0x0A, 0x22, 0x10, 0x12, 0x06, 0x00, 0x00, 0x22, 0x10, 0x00, 0xEE, 0x00, 0x00, 0x00, 0xEE},
+			"0x0200\n  0x020A (x2)\n    0x0210 (x2)\n  0x0210 (x1)\n",
+		},
+		{
+			// CALL 0x204, which adds 1 to V0 and calls itself until V0 is 3
+			"recursive",
+			[]byte{0x22, 0x04, 0x12, 0x02, 0x70, 0x01, 0x30, 0x03, 0x22, 0x04, 0x00, 0xEE},
+			"0x0200\n  0x0204 (x1)\n    0x0204 (x2, recursive)\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c8 := newTestCore(t, tt.rom...)
+			stepN(t, c8, 40)
+
+			if got := c8.CallGraph(); got != tt.want {
+				t.Errorf("CallGraph() =\n%s\nwant\n%s", got, tt.want)
+			}
+
+			// Resetting forgets the calls
+			err := c8.Reset(true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := c8.CallGraph(); got != "0x0200\n" {
+				t.Errorf("CallGraph() after a reset =\n%s", got)
+			}
+		})
+	}
+}
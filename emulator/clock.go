@@ -0,0 +1,36 @@
+package emulator
+
+import "time"
+
+/*
+Source of time for the main loop and anything else that paces itself against the wall clock.
+Everything goes through this instead of the time package so simulated time can be swapped in and advanced by hand.
+*/
+type clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+	Sleep(d time.Duration)
+}
+
+// The default clock, backed by the time package
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) Since(t time.Time) time.Duration {
+	return time.Since(t)
+}
+
+func (realClock) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+// Creates the emulator with the given time source in place of the wall clock, so tests can drive Run() with simulated time
+func withClock(clk clock) Option {
+	return func(c8 *chip8) error {
+		c8.clock = clk
+		return nil
+	}
+}
@@ -0,0 +1,286 @@
+package emulator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Simulated time that only moves when it's slept through or advanced by hand
+type fakeClock struct {
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Unix(0, 0)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Since(t time.Time) time.Duration {
+	return c.now.Sub(t)
+}
+
+func (c *fakeClock) Sleep(d time.Duration) {
+	c.Advance(d)
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	if d > 0 {
+		c.now = c.now.Add(d)
+	}
+}
+
+// Builds a headless machine on a fake clock with rom loaded
+func newClockedCore(t testing.TB, rom ...byte) (*chip8, *fakeClock) {
+	t.Helper()
+
+	clk := newFakeClock()
+
+	c8, err := NewChip8(1, 0, Headless(), withClock(clk))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c8.LoadROMBytes(rom)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return c8, clk
+}
+
+// A run limit that lets exactly n frames through; each frame runs once a whole TIMER_INTERVAL has passed
+func framesLimit(n int) time.Duration {
+	return time.Duration(n)*TIMER_INTERVAL + TIMER_INTERVAL/2
+}
+
+func TestRunWithFakeClock(t *testing.T) {
+	tests := []struct {
+		frames         int
+		cyclesPerFrame int
+	}{
+		{1, 1},
+		{5, 10},
+		{60, 20},
+		{120, 7},
+	}
+
+	for _, tt := range tests {
+		// 7001 1200: count up in V0 forever
+		c8, clk := newClockedCore(t, 0x70, 0x01, 0x12, 0x00)
+		c8.SetCyclesPerFrame(tt.cyclesPerFrame)
+		c8.SetMaxRunTime(framesLimit(tt.frames))
+		c8.delayTimer = 0xFF
+		start := clk.Now()
+
+		err := c8.Run()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if want := uint64(tt.frames * tt.cyclesPerFrame); c8.CycleCount() != want {
+			t.Errorf("%d frames of %d: ran %d cycles, want %d", tt.frames, tt.cyclesPerFrame, c8.CycleCount(), want)
+		}
+
+		if want := byte(0xFF - tt.frames); c8.delayTimer != want {
+			t.Errorf("%d frames: delay timer is %d, want %d", tt.frames, c8.delayTimer, want)
+		}
+
+		if elapsed := clk.Since(start); elapsed < framesLimit(tt.frames) || elapsed > framesLimit(tt.frames+1) {
+			t.Errorf("%d frames: simulated %v passed", tt.frames, elapsed)
+		}
+
+		if !c8.TimedOut() {
+			t.Errorf("%d frames: Run() didn't report timing out", tt.frames)
+		}
+	}
+}
+
+func TestMaxRunTime(t *testing.T) {
+	tests := []struct {
+		name     string
+		rom      []byte
+		onHalt   HaltBehavior
+		wantErr  bool
+		timedOut bool
+	}{
+		{"runs until the limit", []byte{0x70, 0x01, 0x12, 0x00}, HaltIdle, false, true},
+		{"idles on halt until the limit", []byte{0x12, 0x00}, HaltIdle, false, true},
+		{"exits on halt first", []byte{0x12, 0x00}, HaltExit, false, false},
+		{"crashes first", []byte{0x70, 0x01, 0xFF, 0xFF}, HaltIdle, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c8, clk := newClockedCore(t, tt.rom...)
+			c8.SetCyclesPerFrame(10)
+			c8.SetOnHalt(tt.onHalt)
+			c8.SetMaxRunTime(framesLimit(20))
+
+			// A previous run that timed out mustn't leak into this one
+			c8.timedOut = true
+			start := clk.Now()
+
+			err := c8.Run()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Run() error = %v, want error %v", err, tt.wantErr)
+			}
+
+			if c8.TimedOut() != tt.timedOut {
+				t.Errorf("TimedOut() = %v, want %v", c8.TimedOut(), tt.timedOut)
+			}
+
+			if elapsed := clk.Since(start); tt.timedOut && (elapsed < framesLimit(20) || elapsed > framesLimit(21)) {
+				t.Errorf("timed out after %v of simulated time, want %v", elapsed, framesLimit(20))
+			}
+		})
+	}
+}
+
+func TestRunPlaylist(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, rom ...byte) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, rom, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	counting := write("counting.ch8", 0x70, 0x01, 0x12, 0x00)
+	halting := write("halting.ch8", 0x60, 0x07, 0x12, 0x02)
+	crashing := write("crashing.ch8", 0xFF, 0xFF)
+	missing := filepath.Join(dir, "missing.ch8")
+
+	tests := []struct {
+		name  string
+		roms  []string
+		loads int
+		ran   int
+	}{
+		{"missing after two", []string{counting, halting, missing}, 2, 2},
+		{"crash after one", []string{counting, crashing, counting}, 2, 1},
+		{"missing first", []string{missing, counting}, 0, 0},
+		{"empty", nil, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c8, clk := newClockedCore(t)
+			c8.SetCyclesPerFrame(10)
+			loads := 0
+			c8.Subscribe(func(e Event) {
+				if e.Type == EventROMLoaded {
+					loads++
+				}
+			})
+			start := clk.Now()
+
+			// Playlists only end on quit or an error
+			if err := c8.RunPlaylist(tt.roms, framesLimit(5)); err == nil {
+				t.Fatal("playlist ended without an error")
+			}
+
+			if loads != tt.loads {
+				t.Errorf("%d ROMs loaded, want %d", loads, tt.loads)
+			}
+
+			// Each ROM that ran to the end of its slot used up the whole slot; a crash can take a frame to happen
+			slots := time.Duration(tt.ran) * framesLimit(5)
+			if elapsed := clk.Since(start); elapsed < slots || elapsed > slots+2*TIMER_INTERVAL {
+				t.Errorf("playlist took %v of simulated time for %d ROMs of %v", elapsed, tt.ran, framesLimit(5))
+			}
+		})
+	}
+}
+
+func TestTimersTickAt60Hz(t *testing.T) {
+	tests := []struct {
+		name       string
+		cycleDelay float64
+		cycles     uint64
+	}{
+		{"1ms delay", 1, 1008},
+		{"5ms delay", 5, 201},
+		{"50ms delay", 50, 20},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 7001 1200: count up in V0 forever
+			c8, _ := newClockedCore(t, 0x70, 0x01, 0x12, 0x00)
+			c8.cycleDelay = tt.cycleDelay
+			c8.SetMaxRunTime(time.Second + TIMER_INTERVAL/2)
+			c8.delayTimer = 0xFF
+			c8.soundTimer = 0xFF
+
+			err := c8.Run()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// However many instructions ran in the extra half tick, a second is 60 ticks
+			if got := c8.CycleCount(); got < tt.cycles-1 || got > tt.cycles+1 {
+				t.Errorf("ran %d cycles, want about %d", got, tt.cycles)
+			}
+
+			if c8.delayTimer != 0xFF-60 || c8.soundTimer != 0xFF-60 {
+				t.Errorf("delay timer %d and sound timer %d, want both %d", c8.delayTimer, c8.soundTimer, 0xFF-60)
+			}
+		})
+	}
+}
+
+func TestPausedRun(t *testing.T) {
+	tests := []struct {
+		name   string
+		paused bool
+		cycles uint64
+		delay  byte
+	}{
+		{"running", false, 100, 0xFF - 10},
+		{"paused", true, 0, 0xFF},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 7001 1200: count up in V0 forever
+			c8, _ := newClockedCore(t, 0x70, 0x01, 0x12, 0x00)
+			renderer := &captureRenderer{}
+			c8.SetRenderer(renderer)
+			c8.SetCyclesPerFrame(10)
+			c8.delayTimer = 0xFF
+			if tt.paused {
+				c8.Pause()
+			}
+
+			c8.SetMaxRunTime(framesLimit(10))
+			if err := c8.Run(); err != nil {
+				t.Fatal(err)
+			}
+
+			if c8.CycleCount() != tt.cycles || c8.delayTimer != tt.delay {
+				t.Errorf("%d cycles with the delay timer at %d, want %d at %d", c8.CycleCount(), c8.delayTimer, tt.cycles, tt.delay)
+			}
+
+			// Frames are still drawn while paused
+			if renderer.frames == 0 {
+				t.Error("no frames presented")
+			}
+
+			// Resuming carries on from where it stopped, without catching up on the paused time
+			c8.Resume()
+			if err := c8.Run(); err != nil {
+				t.Fatal(err)
+			}
+
+			if want := tt.cycles + 100; c8.CycleCount() != want {
+				t.Errorf("%d cycles after resuming, want %d", c8.CycleCount(), want)
+			}
+		})
+	}
+}
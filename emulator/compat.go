@@ -0,0 +1,69 @@
+package emulator
+
+import (
+	"crypto/sha256"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+/*
+The compatibility database: recommended settings for known ROMs, keyed by ROMID. Entries spell out every quirk, so
+applying one gives the same machine whatever was set before.
+*/
+//go:embed compat.json
+var compatibilityJSON []byte
+
+// Recommended platform, quirks and speed for a ROM
+type Profile struct {
+	Name           string   `json:"name"`
+	Platform       Platform `json:"platform"`
+	IncrementIndex bool     `json:"incrementIndex"`
+	ShiftUsesVy    bool     `json:"shiftUsesVy"`
+	JumpUsesVx     bool     `json:"jumpUsesVx"`
+	CyclesPerFrame int      `json:"cyclesPerFrame"`
+}
+
+// The database parsed on first use; it's embedded, so an entry that fails to parse is a build mistake
+var compatibilityProfiles = sync.OnceValue(func() map[string]Profile {
+	profiles := map[string]Profile{}
+	err := json.Unmarshal(compatibilityJSON, &profiles)
+	if err != nil {
+		panic(fmt.Sprintf("compat.json: %v", err))
+	}
+
+	return profiles
+})
+
+// Returns the ID a ROM is looked up by in the compatibility database: the hex SHA-256 of its bytes
+func ROMID(rom []byte) string {
+	return fmt.Sprintf("%x", sha256.Sum256(rom))
+}
+
+// Returns the compatibility database's profile for the ROM with the given ROMID, if it has one
+func LookupCompatibility(romID string) (Profile, bool) {
+	profile, ok := compatibilityProfiles()[romID]
+	return profile, ok
+}
+
+/*
+Applies a profile's platform, quirks and cycles per frame. The platform goes first, so the profile's quirks take
+the place of the platform preset's.
+*/
+func (c8 *chip8) ApplyProfile(profile Profile) {
+	c8.SetPlatform(profile.Platform)
+	c8.SetIncrementIndexOnLoadStore(profile.IncrementIndex)
+	c8.SetShiftUsesVy(profile.ShiftUsesVy)
+	c8.SetBxnnJumpQuirk(profile.JumpUsesVx)
+	c8.SetCyclesPerFrame(profile.CyclesPerFrame)
+}
+
+/*
+When enabled, loading a ROM looks it up in the compatibility database and applies its profile if it has one,
+replacing the platform, quirks and cycles per frame set so far. ROMs that aren't in the database keep the current
+settings. Disabled by default.
+*/
+func (c8 *chip8) SetCompatibilityProfiles(enabled bool) {
+	c8.compatProfiles = enabled
+}
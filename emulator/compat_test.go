@@ -0,0 +1,98 @@
+package emulator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// The profile compat.json seeds for the bundled Pong ROM
+var pongProfile = Profile{
+	Name:           "Pong (1 player)",
+	Platform:       PlatformCHIP8,
+	IncrementIndex: true,
+	ShiftUsesVy:    true,
+	JumpUsesVx:     false,
+	CyclesPerFrame: 10,
+}
+
+func TestLookupCompatibility(t *testing.T) {
+	pong, err := os.ReadFile(filepath.Join("..", "roms", "pong.ch8"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		romID string
+		want  Profile
+		found bool
+	}{
+		{"known ROM", ROMID(pong), pongProfile, true},
+		{"seeded ID", "1db31d734b9352f96aa5e11d9a3085b043a04f21cc793ac9bfde62f857f983e9", pongProfile, true},
+		{"patched ROM", ROMID(append(pong, 0x00)), Profile{}, false},
+		{"not an ID", "pong.ch8", Profile{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile, ok := LookupCompatibility(tt.romID)
+			if profile != tt.want || ok != tt.found {
+				t.Errorf("LookupCompatibility(%q) = %+v, %t, want %+v, %t", tt.romID, profile, ok, tt.want, tt.found)
+			}
+		})
+	}
+}
+
+func TestCompatibilityProfilesAtLoad(t *testing.T) {
+	tests := []struct {
+		name     string
+		enabled  bool
+		rom      string
+		platform Platform
+		shift    bool
+		cycles   int
+	}{
+		{"applied to a known ROM", true, "pong.ch8", PlatformCHIP8, true, 10},
+		{"disabled", false, "pong.ch8", PlatformSCHIP, false, 700},
+		{"unknown ROM keeps the settings", true, "counter.ch8", PlatformSCHIP, false, 700},
+	}
+
+	// 7001 1200: count up in V0 forever
+	counter := filepath.Join(t.TempDir(), "counter.ch8")
+	if err := os.WriteFile(counter, []byte{0x70, 0x01, 0x12, 0x00}, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c8 := newTestCore(t)
+			c8.SetPlatform(PlatformSCHIP)
+			c8.SetCyclesPerFrame(700)
+			c8.SetCompatibilityProfiles(tt.enabled)
+
+			path := filepath.Join("..", "roms", tt.rom)
+			if tt.rom == "counter.ch8" {
+				path = counter
+			}
+			if err := c8.LoadChip8ROM(path); err != nil {
+				t.Fatal(err)
+			}
+
+			if c8.Platform() != tt.platform || c8.shiftUsesVy != tt.shift || c8.cyclesPerFrame != tt.cycles {
+				t.Errorf("platform %q, shift quirk %t, %d cycles per frame, want %q, %t, %d", c8.Platform(), c8.shiftUsesVy, c8.cyclesPerFrame, tt.platform, tt.shift, tt.cycles)
+			}
+		})
+	}
+}
+
+func TestCompatibilityDatabase(t *testing.T) {
+	for id, profile := range compatibilityProfiles() {
+		if _, err := ParsePlatform(string(profile.Platform)); err != nil || profile.Platform == PlatformNone {
+			t.Errorf("%s names platform %q", id, profile.Platform)
+		}
+		if len(id) != 64 {
+			t.Errorf("%s isn't a ROMID", id)
+		}
+	}
+}
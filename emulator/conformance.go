@@ -0,0 +1,102 @@
+package emulator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Seed for Cxkk in tooling runs, so ROMs that use random numbers still produce the same output every time
+const CONFORMANCE_SEED = 0xC8
+
+/*
+Runs a ROM for the given number of cycles without opening a window and returns a report of the display hash and
+registers. The format is stable so the output can be diffed against reports produced by reference emulators.
+*/
+func Conformance(romFile string, cycles uint64) (string, error) {
+	c8 := newCore(1, 0)
+
+	err := c8.LoadChip8ROM(romFile)
+	if err != nil {
+		return "", err
+	}
+
+	return c8.RunConformance(cycles)
+}
+
+/*
+Runs the loaded ROM the way Conformance does, for callers that already have an emulator and want to inspect it
+afterwards, e.g. for its opcode stats. Call it on a headless emulator straight after loading the ROM.
+*/
+func (c8 *chip8) RunConformance(cycles uint64) (string, error) {
+	c8.SetRandomSeed(CONFORMANCE_SEED)
+
+	for c8.cycleCount < cycles {
+		err := c8.headlessCycle()
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return c8.conformanceReport(), nil
+}
+
+func (c8 *chip8) conformanceReport() string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "cycles: %d\n", c8.cycleCount)
+	fmt.Fprintf(&sb, "display: %s\n", c8.DisplayHash())
+	fmt.Fprintf(&sb, "pc: 0x%04X\n", c8.programCounter)
+	fmt.Fprintf(&sb, "i: 0x%04X\n", c8.indexRegister)
+	fmt.Fprintf(&sb, "sp: 0x%02X\n", c8.stackPointer)
+	fmt.Fprintf(&sb, "dt: 0x%02X\n", c8.delayTimer)
+	fmt.Fprintf(&sb, "st: 0x%02X\n", c8.soundTimer)
+
+	for k, v := range c8.registers {
+		fmt.Fprintf(&sb, "v%X: 0x%02X\n", k, v)
+	}
+
+	return sb.String()
+}
+
+/*
+Runs two ROMs side by side without a window for the given number of cycles and returns the first frame at which
+their displays differ, or -1 if they match for the whole run. A frame is HEADLESS_CYCLES_PER_TICK cycles, one timer
+tick, and the displays are compared at the end of each, so a pixel drawn and erased within a frame doesn't count.
+Useful for checking that a patched ROM still behaves like the original.
+*/
+func CompareRuns(romA []byte, romB []byte, cycles int) (int, error) {
+	a := newCore(1, 0)
+	b := newCore(1, 0)
+	a.SetRandomSeed(CONFORMANCE_SEED)
+	b.SetRandomSeed(CONFORMANCE_SEED)
+
+	err := a.loadROM(romA)
+	if err != nil {
+		return 0, err
+	}
+
+	err = b.loadROM(romB)
+	if err != nil {
+		return 0, err
+	}
+
+	for cycle := range cycles {
+		err := a.headlessCycle()
+		if err != nil {
+			return 0, err
+		}
+
+		err = b.headlessCycle()
+		if err != nil {
+			return 0, err
+		}
+
+		// A run that stops partway through a frame still compares what that frame had drawn
+		frameEnd := (cycle+1)%HEADLESS_CYCLES_PER_TICK == 0 || cycle == cycles-1
+		if frameEnd && a.DisplayHash() != b.DisplayHash() {
+			return cycle / HEADLESS_CYCLES_PER_TICK, nil
+		}
+	}
+
+	return -1, nil
+}
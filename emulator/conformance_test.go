@@ -0,0 +1,109 @@
+package emulator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestConformance(t *testing.T) {
+	tests := []struct {
+		rom     string
+		cycles  uint64
+		display string
+		pc      string
+	}{
+		{"1-chip8-logo.ch8", 100, "b3660d8e91d33c1111ed1f5df5655b8a54d63290ae43a989066eb3409017b38d", "0x024E"},
+		{"pong.ch8", 2000, "040d36cd1bdd6ba4a1f215dfb3adf29fbaee1934ea445b4056b32c256d7606bb", "0x021A"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rom, func(t *testing.T) {
+			report, err := Conformance(filepath.Join("..", "roms", tt.rom), tt.cycles)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			lines := strings.Split(strings.TrimSuffix(report, "\n"), "\n")
+			if len(lines) != 7+16 {
+				t.Fatalf("report has %d lines, want %d:\n%s", len(lines), 7+16, report)
+			}
+
+			for k, want := range []string{fmt.Sprint("cycles: ", tt.cycles), "display: " + tt.display, "pc: " + tt.pc} {
+				if lines[k] != want {
+					t.Errorf("line %d = %q, want %q", k+1, lines[k], want)
+				}
+			}
+
+			// A second run reports exactly the same, random numbers included
+			again, err := Conformance(filepath.Join("..", "roms", tt.rom), tt.cycles)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if again != report {
+				t.Errorf("second run reported\n%s\nfirst reported\n%s", again, report)
+			}
+		})
+	}
+}
+
+func TestConformanceErrors(t *testing.T) {
+	dir := t.TempDir()
+	crashing := filepath.Join(dir, "crash.ch8")
+	if err := os.WriteFile(crashing, []byte{0xFF, 0xFF}, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, romFile := range []string{filepath.Join(dir, "missing.ch8"), crashing} {
+		if _, err := Conformance(romFile, 10); err == nil {
+			t.Errorf("Conformance(%q) didn't fail", filepath.Base(romFile))
+		}
+	}
+}
+
+func TestCompareRuns(t *testing.T) {
+	// LD V0, 1; LD I, 0x050; CLS eight times to fill the first frame; LD V1, 4; then the font's 0 at (1, 4) in frame 1, and JP to itself
+	rom := []byte{0x60, 0x01, 0xA0, 0x50}
+	rom = append(rom, slices.Repeat([]byte{0x00, 0xE0}, 8)...)
+	rom = append(rom, 0x61, 0x04, 0xD0, 0x15, 0x12, 0x18)
+
+	modified := func(offset int, values ...byte) []byte {
+		b := slices.Clone(rom)
+		copy(b[offset:], values)
+		return b
+	}
+
+	tests := []struct {
+		name   string
+		b      []byte
+		cycles int
+		want   int
+	}{
+		{"against itself", rom, 40, -1},
+		{"draw moved", modified(21, 0x05), 40, 1},
+		// F201 takes the place of the last CLS, selecting only the second plane for the draw
+		{"draw into the second plane", modified(18, 0xF2, 0x01), 40, 1},
+		{"draw dropped", modified(22, 0x00), 40, 1},
+		{"extra draw ending the first frame", modified(18, 0xD0, 0x15), 40, 0},
+		{"drawn and erased within a frame", modified(16, 0xD0, 0x15, 0xD0, 0x15), 40, -1},
+		{"run ends partway through a frame", modified(21, 0x05), 12, 1},
+		{"run ends before the draw", modified(21, 0x05), 11, -1},
+		{"unused byte", append(slices.Clone(rom), 0xFF), 40, -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frame, err := CompareRuns(rom, tt.b, tt.cycles)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if frame != tt.want {
+				t.Errorf("first divergent frame = %d, want %d", frame, tt.want)
+			}
+		})
+	}
+}
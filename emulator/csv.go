@@ -0,0 +1,37 @@
+package emulator
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+)
+
+/*
+Writes V0-VF, the program counter and the index register to w as CSV once per frame, starting with a header row.
+Handy for plotting how a game's state changes over time.
+*/
+func (c8 *chip8) EnableRegisterCSV(w io.Writer) error {
+	c8.registerCSV = csv.NewWriter(w)
+
+	header := []string{}
+	for k := range c8.registers {
+		header = append(header, fmt.Sprintf("V%X", k))
+	}
+	header = append(header, "PC", "I")
+
+	c8.registerCSV.Write(header)
+	c8.registerCSV.Flush()
+
+	return c8.registerCSV.Error()
+}
+
+func (c8 *chip8) writeRegisterRow() {
+	row := []string{}
+	for _, v := range c8.registers {
+		row = append(row, fmt.Sprintf("0x%02X", v))
+	}
+	row = append(row, fmt.Sprintf("0x%04X", c8.programCounter), fmt.Sprintf("0x%04X", c8.indexRegister))
+
+	c8.registerCSV.Write(row)
+	c8.registerCSV.Flush()
+}
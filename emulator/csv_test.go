@@ -0,0 +1,42 @@
+package emulator
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+)
+
+func TestRegisterCSV(t *testing.T) {
+	// LD V3, 0x2A; LD I, 0x300; then ADD V4, 1 and JP 0x204 forever, an even number of cycles per loop
+	c8, _ := newClockedCore(t, 0x63, 0x2A, 0xA3, 0x00, 0x74, 0x01, 0x12, 0x04)
+	var out bytes.Buffer
+	if err := c8.EnableRegisterCSV(&out); err != nil {
+		t.Fatal(err)
+	}
+	c8.SetCyclesPerFrame(10)
+	c8.SetMaxRunTime(framesLimit(3))
+
+	if err := c8.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header := rows[0]
+	if len(header) != 18 || header[0] != "V0" || header[15] != "VF" || header[16] != "PC" || header[17] != "I" {
+		t.Fatalf("header = %v", header)
+	}
+
+	if frames := len(rows) - 1; frames != 3 {
+		t.Fatalf("%d rows written, want one for each of the 3 frames", frames)
+	}
+
+	for _, row := range rows[1:] {
+		if row[3] != "0x2A" || row[16] != "0x0204" || row[17] != "0x0300" {
+			t.Errorf("row = %v, want V3 0x2A, PC 0x0204 and I 0x0300", row)
+		}
+	}
+}
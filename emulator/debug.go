@@ -0,0 +1,114 @@
+package emulator
+
+import "fmt"
+
+// Upper bound on cycles executed by the run-until helpers before giving up
+const MAX_DEBUG_CYCLES = 1_000_000
+
+// Returns the opcode at the program counter without executing it, or 0 if there's no whole instruction there
+func (c8 *chip8) peekOpcode() uint16 {
+	if int(c8.programCounter)+1 >= len(c8.memory) {
+		return 0
+	}
+
+	return uint16(c8.memory[c8.programCounter])<<8 | uint16(c8.memory[c8.programCounter+1])
+}
+
+/*
+Executes exactly one instruction and returns its opcode. Timers are left alone, so a sequence of steps is fully
+deterministic; use the timer registers directly if a test needs them to move.
+An instruction that can't be decoded is returned as an error wrapping ErrUnknownOpcode, and one that reaches outside
+of memory, including a program counter with no instruction to fetch, as an error wrapping ErrOutOfBounds.
+*/
+func (c8 *chip8) Step() (uint16, error) {
+	err := c8.cycle()
+
+	return c8.opcode, err
+}
+
+/*
+Runs cycles until the next instruction is a Dxyn draw, stopping just before it executes so the machine can be
+inspected before and after the draw. At least one instruction always runs, so when stopped at a draw the next call
+executes it and moves on to the draw after. Returns an error if no draw is reached within MAX_DEBUG_CYCLES.
+*/
+func (c8 *chip8) StepToNextDraw() error {
+	for range MAX_DEBUG_CYCLES {
+		err := c8.headlessCycle()
+		if err != nil {
+			return err
+		}
+
+		if c8.peekOpcode()&0xF000 == 0xD000 {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("no draw instruction reached within %d cycles", MAX_DEBUG_CYCLES)
+}
+
+// Returns the memory addresses written by the most recent cycle, in the order they were written
+func (c8 *chip8) LastStepWrites() []uint16 {
+	return append([]uint16{}, c8.stepWrites...)
+}
+
+/*
+Runs cycles until the display hash matches hash, for scripted automation like waiting for a title screen.
+Returns false once maxCycles have run without a match.
+*/
+func (c8 *chip8) RunUntilDisplayHash(hash string, maxCycles int) (bool, error) {
+	if len(hash) != 64 {
+		return false, fmt.Errorf("%q is not a display hash", hash)
+	}
+
+	for range maxCycles {
+		if c8.DisplayHash() == hash {
+			return true, nil
+		}
+
+		err := c8.headlessCycle()
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return c8.DisplayHash() == hash, nil
+}
+
+// Stops RunUntilBreak() when the program counter reaches addr
+func (c8 *chip8) SetBreakpoint(addr uint16) {
+	if c8.breakpoints == nil {
+		c8.breakpoints = make(map[uint16]bool)
+	}
+
+	c8.breakpoints[addr] = true
+}
+
+// Removes a breakpoint set with SetBreakpoint
+func (c8 *chip8) ClearBreakpoint(addr uint16) {
+	delete(c8.breakpoints, addr)
+}
+
+/*
+Runs cycles until the program counter reaches a breakpoint, stopping before the instruction there executes, or until
+the program halts. Returns the address it stopped at. At least one instruction always runs, so calling it again
+while sitting on a breakpoint carries on to the next one. Returns an error if neither happens within MAX_DEBUG_CYCLES.
+*/
+func (c8 *chip8) RunUntilBreak() (uint16, error) {
+	for range MAX_DEBUG_CYCLES {
+		err := c8.headlessCycle()
+		if err != nil {
+			return c8.programCounter, err
+		}
+
+		if c8.breakpoints[c8.programCounter] {
+			c8.emit(EventBreakpoint)
+			return c8.programCounter, nil
+		}
+
+		if c8.halted {
+			return c8.programCounter, nil
+		}
+	}
+
+	return c8.programCounter, fmt.Errorf("no breakpoint reached within %d cycles", MAX_DEBUG_CYCLES)
+}
@@ -0,0 +1,199 @@
+package emulator
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestStepToNextDraw(t *testing.T) {
+	// CLS; DRW; ADD V0, 8; DRW; DRW; JP 0x20A
+	c8 := newTestCore(t, 0x00, 0xE0, 0xD0, 0x11, 0x70, 0x08, 0xD0, 0x11, 0xD0, 0x11, 0x12, 0x0A)
+
+	stops := []uint16{}
+	for range 3 {
+		if err := c8.StepToNextDraw(); err != nil {
+			t.Fatal(err)
+		}
+		stops = append(stops, c8.programCounter)
+	}
+
+	if want := []uint16{0x202, 0x206, 0x208}; !slices.Equal(stops, want) {
+		t.Errorf("stopped at % X, want % X", stops, want)
+	}
+
+	// Past the last draw there are none left to reach
+	if err := c8.StepToNextDraw(); err == nil {
+		t.Errorf("stopped at 0x%04X with no draw left", c8.programCounter)
+	}
+}
+
+func TestLastStepWrites(t *testing.T) {
+	tests := []struct {
+		name string
+		rom  []byte
+		want []uint16
+		mem  []byte
+	}{
+		// LD V0, 123; LD I, 0x300; LD B, V0
+		{"Fx33", []byte{0x60, 0x7B, 0xA3, 0x00, 0xF0, 0x33}, []uint16{0x300, 0x301, 0x302}, []byte{1, 2, 3}},
+		// LD V0, 1; LD I, 0x300; LD [I], V2
+		{"Fx55", []byte{0x60, 0x01, 0xA3, 0x00, 0xF2, 0x55}, []uint16{0x300, 0x301, 0x302}, []byte{1, 0, 0}},
+		// LD V0, 1; LD I, 0x300; LD V2, [I]
+		{"Fx65 only reads", []byte{0x60, 0x01, 0xA3, 0x00, 0xF2, 0x65}, []uint16{}, []byte{0, 0, 0}},
+		// LD V0, 1; LD I, 0x050; DRW V0, V0, 5 only changes the display
+		{"Dxyn", []byte{0x60, 0x01, 0xA0, 0x50, 0xD0, 0x05}, []uint16{}, []byte{0, 0, 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The ROM is followed by JP to itself
+			rom := append(slices.Clone(tt.rom), 0x12, byte(len(tt.rom)))
+			c8 := newTestCore(t, rom...)
+			stepN(t, c8, 3)
+
+			if got := c8.LastStepWrites(); !slices.Equal(got, tt.want) {
+				t.Errorf("writes % X, want % X", got, tt.want)
+			}
+
+			if got := c8.Memory()[0x300:0x303]; !slices.Equal(got, tt.mem) {
+				t.Errorf("memory at 0x300 = % X, want % X", got, tt.mem)
+			}
+
+			// The next step forgets them
+			stepN(t, c8, 1)
+			if got := c8.LastStepWrites(); len(got) != 0 {
+				t.Errorf("writes % X after a jump", got)
+			}
+		})
+	}
+}
+
+func TestRunUntilDisplayHash(t *testing.T) {
+	// LD I, 0x050; DRW V0, V1, 5; ADD V0, 8; DRW V0, V1, 5; ADD V0, 8; DRW V0, V1, 5; JP to itself
+	rom := []byte{0xA0, 0x50, 0xD0, 0x15, 0x70, 0x08, 0xD0, 0x15, 0x70, 0x08, 0xD0, 0x15, 0x12, 0x0C}
+
+	// Capture the display after the first and second draws
+	hashes := []string{}
+	c8 := newTestCore(t, rom...)
+	for _, steps := range []int{2, 2} {
+		stepN(t, c8, steps)
+		hashes = append(hashes, c8.DisplayHash())
+	}
+
+	tests := []struct {
+		name      string
+		hash      string
+		maxCycles int
+		found     bool
+		pc        uint16
+		wantErr   bool
+	}{
+		{"first draw", hashes[0], 100, true, 0x204, false},
+		{"second draw", hashes[1], 100, true, 0x208, false},
+		{"exactly within budget", hashes[1], 4, true, 0x208, false},
+		{"budget too small", hashes[1], 3, false, 0x206, false},
+		{"never shown", strings.Repeat("0", 64), 20, false, 0x20C, false},
+		{"not a hash", "title screen", 20, false, 0x200, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := c8.Reset(true); err != nil {
+				t.Fatal(err)
+			}
+
+			found, err := c8.RunUntilDisplayHash(tt.hash, tt.maxCycles)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RunUntilDisplayHash() error = %v, want error %t", err, tt.wantErr)
+			}
+
+			if found != tt.found || c8.programCounter != tt.pc {
+				t.Errorf("found = %t at 0x%04X, want %t at 0x%04X", found, c8.programCounter, tt.found, tt.pc)
+			}
+		})
+	}
+}
+
+func TestStep(t *testing.T) {
+	tests := []struct {
+		name    string
+		rom     []byte
+		opcode  uint16
+		pc      uint16
+		cycles  uint64
+		wantErr bool
+	}{
+		{"load", []byte{0x6A, 0x42}, 0x6A42, 0x202, 1, false},
+		{"jump", []byte{0x13, 0x00}, 0x1300, 0x300, 1, false},
+		{"skip", []byte{0x30, 0x00}, 0x3000, 0x204, 1, false},
+		// A failed instruction isn't counted
+		{"unknown opcode", []byte{0xFF, 0xFF}, 0xFFFF, 0x202, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c8 := newTestCore(t, tt.rom...)
+			c8.delayTimer = 10
+			c8.soundTimer = 10
+
+			opcode, err := c8.Step()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Step() error = %v, want error %t", err, tt.wantErr)
+			}
+
+			if opcode != tt.opcode || c8.programCounter != tt.pc {
+				t.Errorf("Step() ran 0x%04X to 0x%04X, want 0x%04X to 0x%04X", opcode, c8.programCounter, tt.opcode, tt.pc)
+			}
+
+			// At most one instruction, and the timers are left alone
+			if c8.CycleCount() != tt.cycles || c8.delayTimer != 10 || c8.soundTimer != 10 {
+				t.Errorf("%d cycles with timers at %d and %d, want %d with both at 10", c8.CycleCount(), c8.delayTimer, c8.soundTimer, tt.cycles)
+			}
+		})
+	}
+}
+
+func TestRunUntilBreak(t *testing.T) {
+	// ADD V0, 1; SE V0, 3; JP 0x200; LD V1, 7; JP to itself
+	rom := []byte{0x70, 0x01, 0x30, 0x03, 0x12, 0x00, 0x61, 0x07, 0x12, 0x08}
+
+	tests := []struct {
+		name    string
+		set     []uint16
+		cleared []uint16
+		stops   []uint16
+	}{
+		{"none", nil, nil, []uint16{0x208}},
+		{"in the loop", []uint16{0x202}, nil, []uint16{0x202, 0x202, 0x202, 0x208}},
+		{"after the loop", []uint16{0x206}, nil, []uint16{0x206, 0x208}},
+		{"two", []uint16{0x200, 0x206}, nil, []uint16{0x200, 0x200, 0x206, 0x208}},
+		{"cleared", []uint16{0x202, 0x206}, []uint16{0x202}, []uint16{0x206, 0x208}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c8 := newTestCore(t, rom...)
+			for _, addr := range tt.set {
+				c8.SetBreakpoint(addr)
+			}
+			for _, addr := range tt.cleared {
+				c8.ClearBreakpoint(addr)
+			}
+
+			// Each call carries on from the last stop until the program halts
+			stops := []uint16{}
+			for len(stops) < 10 && !c8.halted {
+				pc, err := c8.RunUntilBreak()
+				if err != nil {
+					t.Fatal(err)
+				}
+				stops = append(stops, pc)
+			}
+
+			if !slices.Equal(stops, tt.stops) {
+				t.Errorf("stopped at % X, want % X", stops, tt.stops)
+			}
+		})
+	}
+}
@@ -0,0 +1,233 @@
+package emulator
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// A decoded instruction from a ROM
+type Instruction struct {
+	// Where the instruction sits once the ROM is loaded at START_ADDRESS
+	Address uint16
+	Opcode  uint16
+
+	// The instruction's form, e.g. "LD Vx, byte", and its operands filled in, e.g. ["V3", "0x05"]
+	Mnemonic string
+	Operands []string
+}
+
+// Renders the instruction the way it would be written, e.g. "LD V3, 0x05"
+func (in Instruction) String() string {
+	name, _, _ := strings.Cut(in.Mnemonic, " ")
+	if len(in.Operands) == 0 {
+		return name
+	}
+
+	return name + " " + strings.Join(in.Operands, ", ")
+}
+
+/*
+Decodes a ROM two bytes at a time into its instruction stream, addressed as if loaded at START_ADDRESS.
+Data mixed in with the code decodes as whatever instruction it happens to look like, and words that aren't any
+instruction are given as "DW word" rather than failing. A trailing odd byte is given as "DB byte".
+*/
+func Disassemble(rom []byte) ([]Instruction, error) {
+	available := EXTENDED_MEMORY_SIZE - int(START_ADDRESS)
+	if len(rom) > available {
+		return nil, fmt.Errorf("ROM is %d bytes, larger than the %d bytes available from 0x%03X", len(rom), available, START_ADDRESS)
+	}
+
+	instructions := []Instruction{}
+
+	for offset := 0; offset < len(rom); offset += 2 {
+		address := uint16(int(START_ADDRESS) + offset)
+
+		if offset+1 == len(rom) {
+			instructions = append(instructions, Instruction{
+				Address:  address,
+				Opcode:   uint16(rom[offset]),
+				Mnemonic: "DB byte",
+				Operands: []string{fmt.Sprintf("0x%02X", rom[offset])},
+			})
+			break
+		}
+
+		opcode := uint16(rom[offset])<<8 | uint16(rom[offset+1])
+		in := decodeInstruction(address, opcode)
+
+		// F000 NNNN carries its address in the following word, which is never executed
+		if opcodePattern(opcode) == "F000" {
+			if offset+3 >= len(rom) {
+				in = dataWord(address, opcode)
+			} else {
+				long := uint16(rom[offset+2])<<8 | uint16(rom[offset+3])
+				in.Operands = []string{"I", fmt.Sprintf("0x%04X", long)}
+				offset += 2
+			}
+		}
+
+		instructions = append(instructions, in)
+	}
+
+	return instructions, nil
+}
+
+/*
+Writes the ROM's disassembly to w as a listing, one instruction per line with its address and raw bytes, e.g.
+"0x0200  6005       LD V0, 0x05". F000 NNNN shows both of its words.
+*/
+func WriteDisassembly(w io.Writer, rom []byte) error {
+	instructions, err := Disassemble(rom)
+	if err != nil {
+		return err
+	}
+
+	for k, in := range instructions {
+		start := int(in.Address) - int(START_ADDRESS)
+		end := len(rom)
+		if k+1 < len(instructions) {
+			end = int(instructions[k+1].Address) - int(START_ADDRESS)
+		}
+
+		words := []string{}
+		for offset := start; offset < end; offset += 2 {
+			words = append(words, fmt.Sprintf("%X", rom[offset:min(offset+2, end)]))
+		}
+
+		_, err := fmt.Fprintf(w, "0x%04X  %-9s  %s\n", in.Address, strings.Join(words, " "), in)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Disassembles the ROM file at romFile and writes the listing to outFile, replacing it if it exists
+func ExportDisassembly(romFile string, outFile string) error {
+	rom, err := os.ReadFile(romFile)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(outFile)
+	if err != nil {
+		return err
+	}
+
+	err = WriteDisassembly(file, rom)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// Decodes a single opcode into its instruction form and operands
+func decodeInstruction(address uint16, opcode uint16) Instruction {
+	x := fmt.Sprintf("V%X", (opcode&0x0F00)>>8)
+	y := fmt.Sprintf("V%X", (opcode&0x00F0)>>4)
+	n := fmt.Sprintf("0x%X", opcode&0x000F)
+	kk := fmt.Sprintf("0x%02X", opcode&0x00FF)
+	nnn := fmt.Sprintf("0x%03X", opcode&0x0FFF)
+
+	in := Instruction{Address: address, Opcode: opcode}
+
+	switch opcodePattern(opcode) {
+	case "00E0":
+		in.Mnemonic = "CLS"
+	case "00EE":
+		in.Mnemonic = "RET"
+	case "00Cn":
+		in.Mnemonic, in.Operands = "SCD nibble", []string{n}
+	case "00FB":
+		in.Mnemonic = "SCR"
+	case "00FC":
+		in.Mnemonic = "SCL"
+	case "00FE":
+		in.Mnemonic = "LOW"
+	case "00FF":
+		in.Mnemonic = "HIGH"
+	case "1nnn":
+		in.Mnemonic, in.Operands = "JP addr", []string{nnn}
+	case "2nnn":
+		in.Mnemonic, in.Operands = "CALL addr", []string{nnn}
+	case "3xkk":
+		in.Mnemonic, in.Operands = "SE Vx, byte", []string{x, kk}
+	case "4xkk":
+		in.Mnemonic, in.Operands = "SNE Vx, byte", []string{x, kk}
+	case "5xy0":
+		in.Mnemonic, in.Operands = "SE Vx, Vy", []string{x, y}
+	case "6xkk":
+		in.Mnemonic, in.Operands = "LD Vx, byte", []string{x, kk}
+	case "7xkk":
+		in.Mnemonic, in.Operands = "ADD Vx, byte", []string{x, kk}
+	case "8xy0":
+		in.Mnemonic, in.Operands = "LD Vx, Vy", []string{x, y}
+	case "8xy1":
+		in.Mnemonic, in.Operands = "OR Vx, Vy", []string{x, y}
+	case "8xy2":
+		in.Mnemonic, in.Operands = "AND Vx, Vy", []string{x, y}
+	case "8xy3":
+		in.Mnemonic, in.Operands = "XOR Vx, Vy", []string{x, y}
+	case "8xy4":
+		in.Mnemonic, in.Operands = "ADD Vx, Vy", []string{x, y}
+	case "8xy5":
+		in.Mnemonic, in.Operands = "SUB Vx, Vy", []string{x, y}
+	case "8xy6":
+		in.Mnemonic, in.Operands = "SHR Vx {, Vy}", []string{x, y}
+	case "8xy7":
+		in.Mnemonic, in.Operands = "SUBN Vx, Vy", []string{x, y}
+	case "8xyE":
+		in.Mnemonic, in.Operands = "SHL Vx {, Vy}", []string{x, y}
+	case "9xy0":
+		in.Mnemonic, in.Operands = "SNE Vx, Vy", []string{x, y}
+	case "Annn":
+		in.Mnemonic, in.Operands = "LD I, addr", []string{"I", nnn}
+	case "Bnnn":
+		in.Mnemonic, in.Operands = "JP V0, addr", []string{"V0", nnn}
+	case "Cxkk":
+		in.Mnemonic, in.Operands = "RND Vx, byte", []string{x, kk}
+	case "Dxyn":
+		in.Mnemonic, in.Operands = "DRW Vx, Vy, nibble", []string{x, y, n}
+	case "Ex9E":
+		in.Mnemonic, in.Operands = "SKP Vx", []string{x}
+	case "ExA1":
+		in.Mnemonic, in.Operands = "SKNP Vx", []string{x}
+	case "F000":
+		in.Mnemonic = "LD I, long addr"
+	case "Fn01":
+		in.Mnemonic, in.Operands = "PLANE n", []string{fmt.Sprintf("%d", (opcode&0x0F00)>>8)}
+	case "Fx07":
+		in.Mnemonic, in.Operands = "LD Vx, DT", []string{x, "DT"}
+	case "Fx0A":
+		in.Mnemonic, in.Operands = "LD Vx, K", []string{x, "K"}
+	case "Fx15":
+		in.Mnemonic, in.Operands = "LD DT, Vx", []string{"DT", x}
+	case "Fx18":
+		in.Mnemonic, in.Operands = "LD ST, Vx", []string{"ST", x}
+	case "Fx1E":
+		in.Mnemonic, in.Operands = "ADD I, Vx", []string{"I", x}
+	case "Fx29":
+		in.Mnemonic, in.Operands = "LD F, Vx", []string{"F", x}
+	case "Fx30":
+		in.Mnemonic, in.Operands = "LD HF, Vx", []string{"HF", x}
+	case "Fx33":
+		in.Mnemonic, in.Operands = "LD B, Vx", []string{"B", x}
+	case "Fx55":
+		in.Mnemonic, in.Operands = "LD [I], Vx", []string{"[I]", x}
+	case "Fx65":
+		in.Mnemonic, in.Operands = "LD Vx, [I]", []string{x, "[I]"}
+	default:
+		return dataWord(address, opcode)
+	}
+
+	return in
+}
+
+// Gives a word that isn't an instruction as data
+func dataWord(address uint16, opcode uint16) Instruction {
+	return Instruction{Address: address, Opcode: opcode, Mnemonic: "DW word", Operands: []string{fmt.Sprintf("0x%04X", opcode)}}
+}
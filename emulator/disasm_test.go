@@ -0,0 +1,127 @@
+package emulator
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestExportDisassembly(t *testing.T) {
+	tests := []struct {
+		name string
+		rom  []byte
+		want string
+	}{
+		{
+			"instructions",
+			[]byte{0x60, 0x05, 0xA2, 0x2A, 0xD0, 0x15, 0x12, 0x06},
+			"0x0200  6005       LD V0, 0x05\n" +
+				"0x0202  A22A       LD I, 0x22A\n" +
+				"0x0204  D015       DRW V0, V1, 0x5\n" +
+				"0x0206  1206       JP 0x206\n",
+		},
+		{
+			"long load",
+			[]byte{0xF0, 0x00, 0x03, 0x00, 0x00, 0xE0},
+			"0x0200  F000 0300  LD I, 0x0300\n" +
+				"0x0204  00E0       CLS\n",
+		},
+		{
+			"data",
+			[]byte{0xFF, 0xFF, 0xA5},
+			"0x0200  FFFF       DW 0xFFFF\n" +
+				"0x0202  A5         DB 0xA5\n",
+		},
+		{"empty", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			romFile := filepath.Join(dir, "rom.ch8")
+			outFile := filepath.Join(dir, "out.asm")
+
+			if err := os.WriteFile(romFile, tt.rom, 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := ExportDisassembly(romFile, outFile); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := os.ReadFile(outFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(got) != tt.want {
+				t.Errorf("listing =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExportDisassemblyMissingROM(t *testing.T) {
+	dir := t.TempDir()
+	outFile := filepath.Join(dir, "out.asm")
+
+	if err := ExportDisassembly(filepath.Join(dir, "missing.ch8"), outFile); err == nil {
+		t.Fatal("disassembling a missing ROM didn't fail")
+	}
+
+	if _, err := os.Stat(outFile); err == nil {
+		t.Error("a listing was written for a missing ROM")
+	}
+}
+
+func TestDisassemble(t *testing.T) {
+	tests := []struct {
+		name      string
+		rom       []byte
+		want      []string
+		addresses []uint16
+	}{
+		{"empty", []byte{}, []string{}, []uint16{}},
+		{
+			"instructions",
+			[]byte{0x00, 0xE0, 0x63, 0x05, 0xA2, 0x34, 0xD1, 0x25, 0x8A, 0xBE, 0xF3, 0x33, 0x12, 0x00},
+			[]string{"CLS", "LD V3, 0x05", "LD I, 0x234", "DRW V1, V2, 0x5", "SHL VA, VB", "LD B, V3", "JP 0x200"},
+			[]uint16{0x200, 0x202, 0x204, 0x206, 0x208, 0x20A, 0x20C},
+		},
+		{"data words", []byte{0x01, 0x23, 0xFF, 0xFF}, []string{"DW 0x0123", "DW 0xFFFF"}, []uint16{0x200, 0x202}},
+		{"trailing byte", []byte{0x00, 0xEE, 0xAB}, []string{"RET", "DB 0xAB"}, []uint16{0x200, 0x202}},
+		{"long load", []byte{0xF0, 0x00, 0x12, 0x34, 0x00, 0xFF}, []string{"LD I, 0x1234", "HIGH"}, []uint16{0x200, 0x204}},
+		{"cut off long load", []byte{0x00, 0xE0, 0xF0, 0x00}, []string{"CLS", "DW 0xF000"}, []uint16{0x200, 0x202}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instructions, err := Disassemble(tt.rom)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := []string{}
+			addresses := []uint16{}
+			for _, in := range instructions {
+				got = append(got, in.String())
+				addresses = append(addresses, in.Address)
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Disassemble() = %q, want %q", got, tt.want)
+			}
+			if !slices.Equal(addresses, tt.addresses) {
+				t.Errorf("addresses % X, want % X", addresses, tt.addresses)
+			}
+		})
+	}
+}
+
+func TestDisassembleTooLarge(t *testing.T) {
+	_, err := Disassemble(make([]byte, EXTENDED_MEMORY_SIZE))
+	if err == nil {
+		t.Error("disassembled a ROM larger than memory")
+	}
+}
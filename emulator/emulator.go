@@ -1,10 +1,15 @@
 package emulator
 
 import (
+	"encoding/csv"
+	"errors"
+	"fmt"
 	"io"
 	"log"
 	"math/rand/v2"
 	"os"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/veandco/go-sdl2/sdl"
@@ -15,25 +20,128 @@ There is relatively little register-space (because it's expensive), so a compute
 memory dedicated to holding program instructions, long-term data, and short-term data. It references different
 locations in that memory using an address.
 
-The CHIP-8 has 4096 bytes of memory, meaning the address space is from 0x000 to 0xFFF.
+The CHIP-8 has 4096 bytes of memory, meaning the address space is from 0x000 to 0xFFF. XO-CHIP ROMs can have it extended to
+64k (0x0000 to 0xFFFF), which only adds free space after 0xFFF.
 The address space is segmented into three sections:
 
 	0x000-0x1FF: Originally reserved for the CHIP-8 interpreter, but in our modern emulator we will just never write to or read from that area. Except for...
 	0x050-0x0A0: Storage space for the 16 built-in characters (0 through F), which we will need to manually put into our memory because ROMs will be looking for those characters.
+	0x0A0-0x140: Storage space for the SUPER-CHIP big font, the same 16 characters drawn 8x10 for the high-res display.
 	0x200-0xFFF: Instructions from the ROM will be stored starting at 0x200, and anything left after the ROM's space is free to use.
 */
 const START_ADDRESS uint = 0x200
+const MEMORY_SIZE = 4096
+const EXTENDED_MEMORY_SIZE = 0x10000
 const FONTSET_START_ADDRESS uint = 0x50
+
+// The built-in 4x5 font for the hex digits 0-F, five bytes per digit with each row in the top nibble
+var fontset = [80]byte{
+	0xF0, 0x90, 0x90, 0x90, 0xF0, // 0
+	0x20, 0x60, 0x20, 0x20, 0x70, // 1
+	0xF0, 0x10, 0xF0, 0x80, 0xF0, // 2
+	0xF0, 0x10, 0xF0, 0x10, 0xF0, // 3
+	0x90, 0x90, 0xF0, 0x10, 0x10, // 4
+	0xF0, 0x80, 0xF0, 0x10, 0xF0, // 5
+	0xF0, 0x80, 0xF0, 0x90, 0xF0, // 6
+	0xF0, 0x10, 0x20, 0x40, 0x40, // 7
+	0xF0, 0x90, 0xF0, 0x90, 0xF0, // 8
+	0xF0, 0x90, 0xF0, 0x10, 0xF0, // 9
+	0xF0, 0x90, 0xF0, 0x90, 0x90, // A
+	0xE0, 0x90, 0xE0, 0x90, 0xE0, // B
+	0xF0, 0x80, 0x80, 0x80, 0xF0, // C
+	0xE0, 0x90, 0x90, 0x90, 0xE0, // D
+	0xF0, 0x80, 0xF0, 0x80, 0xF0, // E
+	0xF0, 0x80, 0xF0, 0x80, 0x80, // F
+}
+
+// SUPER-CHIP's 8x10 font, ten bytes per digit, sits right after the small font in the reserved area
+const BIG_FONTSET_START_ADDRESS uint = 0xA0
 const VIDEO_HEIGHT = 32
 const VIDEO_WIDTH = 64
+
+// SUPER-CHIP's high-resolution display, switched on with 00FF and back off with 00FE
+const HIRES_VIDEO_HEIGHT = 64
+const HIRES_VIDEO_WIDTH = 128
 const WINDOW_TITLE = "Chip8 Emulator"
 
+// How Dxyn combines sprite pixels with the display
+type DrawMode int
+
+const (
+	// Standard CHIP-8 drawing; sprites toggle pixels and overlaps set VF
+	DrawXOR DrawMode = iota
+
+	// Sprites only turn pixels on, used by some experimental modes; VF is never set
+	DrawOR
+)
+
+// The delay and sound timers count down at 60Hz
+const TIMER_INTERVAL = time.Second / 60
+
+// Cycles per timer tick when running without Run(); 10 matches a typical 600 instructions per second
+const HEADLESS_CYCLES_PER_TICK = 10
+
+// Bounds and step size, in milliseconds, for adjusting the cycle delay at runtime
+const MIN_CYCLE_DELAY float64 = 0
+const MAX_CYCLE_DELAY float64 = 100
+const CYCLE_DELAY_STEP float64 = 1
+
+// Bounds for the speed multiplier, and the factor each press of the speed keys multiplies or divides it by
+const MIN_SPEED_MULTIPLIER float64 = 0.25
+const MAX_SPEED_MULTIPLIER float64 = 8
+const SPEED_MULTIPLIER_STEP float64 = 2
+
+// Color of the optional sprite alignment grid
+const GRID_COLOR uint32 = 0xFF404040
+
+// Color of the outline drawn around the last sprite when debug draw bounds are enabled
+const DEBUG_DRAW_COLOR uint32 = 0xFFFF0000
+
+// Color of the speed overlay's digits, and how many window pixels wide each pixel of their font is drawn
+const SPEED_OVERLAY_COLOR uint32 = 0xFF00FF00
+const SPEED_OVERLAY_SCALE int32 = 2
+
+// Value written to VF after operations that leave it undefined when VF poisoning is enabled
+const VF_POISON byte = 0xA5
+
+// What Run() does once the program halts itself with a jump to its own address
+type HaltBehavior int
+
+const (
+	// Keep the window open showing the final frame, with the timers still running, polling input once per timer tick
+	HaltIdle HaltBehavior = iota
+
+	// Return from Run()
+	HaltExit
+)
+
+// How long Run() sleeps between input polls while paused
+const IDLE_POLL_INTERVAL = 50 * time.Millisecond
+
+// Number of nested subroutine calls the stack holds by default, and the most it can be configured to hold
+const DEFAULT_STACK_DEPTH = 16
+const MAX_STACK_DEPTH = 256
+
+// Whether a timer tick that falls due alongside a cycle is applied before or after the cycle executes
+type TimerDecrementOrder int
+
+const (
+	// Timers tick first, so an instruction reading a timer sees the already decremented value
+	TimersBeforeCycle TimerDecrementOrder = iota
+
+	// The instruction executes first and the timers tick afterwards
+	TimersAfterCycle
+)
+
+// How long a status message stays in the window title before it's restored
+const STATUS_DURATION = 2 * time.Second
+
 type chip8 struct {
 	// Chip8 has 16 8-bit registers
 	registers [16]byte
 
-	// 4k bytes of memory
-	memory [4096]byte
+	// 4k bytes of memory, or 64k with XO-CHIP extended memory
+	memory []byte
 
 	// The Index Register is a special register used to store memory addresses for use in operations
 	// It's a 16-bit register because the maximum memory address (0xFFF) is too big for an 8-bit register
@@ -43,16 +151,18 @@ type chip8 struct {
 	// Again, it's 16 bits because it has to be able to hold the maximum memory address (0xFFF)
 	programCounter uint16
 
-	// 16-level stack used to hold PCs. Can push and pull instructions to it for execution flow
-	stack [16]uint16
+	// Stack used to hold PCs. Can push and pull instructions to it for execution flow
+	// CHIP-8 has 16 levels but the depth is configurable for interpreters that allow deeper nesting
+	stack []uint16
 
 	// The Stack Pointer keeps track of our position in the stack
-	stackPointer byte
+	// It's wider than the deepest allowed stack so the bounds checks can't be defeated by wrapping
+	stackPointer uint16
 
 	// The CHIP-8 has a simple timer used for timing
 	// If the timer value is zero, it stays zero
 	// If it is loaded with a value, it will decrement at a rate of 60Hz
-	// We will just be decrementing based on clock cycle for this application
+	// Run() ticks it on a 60Hz wall-clock schedule, separate from the cycle rate
 	delayTimer byte
 
 	// Same behavior as the Delay Timer
@@ -76,24 +186,303 @@ type chip8 struct {
 	*/
 	keypad [16]byte
 
-	// Holds our screen pixels
-	pixels [VIDEO_WIDTH * VIDEO_HEIGHT]uint32
+	// Keys seen held down while Fx0A waits; the wait ends when one of them is let go
+	keyWaitHeld [16]bool
+
+	// Keyboard keys mapped onto the keypad above
+	keyMap KeyMap
+
+	// Controller buttons mapped onto the keypad, and the controllers currently plugged in
+	buttonMap   ButtonMap
+	controllers map[sdl.JoystickID]*sdl.GameController
+
+	// Optional record of keypad transitions
+	inputLogEnabled bool
+	inputLog        []InputEvent
+
+	// Holds our screen pixels, sized for high-res mode; in low-res mode only the first VIDEO_WIDTH * VIDEO_HEIGHT are used
+	pixels displayPlane
+	hires  bool
+
+	/*
+		Guards the display, so other goroutines can use SetDisplayBuffer, GetPixel, FillDisplayPattern,
+		ScaledFramebuffer and DisplayHash while Run() is going. It's held for each whole instruction and render, so the
+		callbacks run inside an instruction (subscribers, opcode handlers and the instruction filter) mustn't call them.
+	*/
+	stateLock sync.Mutex
+
+	// XO-CHIP's second bitplane, which planes Dxyn, 00E0 and scrolling act on, and the color of each plane combination
+	plane2      displayPlane
+	planeMask   byte
+	planeColors [1 << PLANE_COUNT]uint32
 
 	// SDL2 specific properties
 	window  *sdl.Window
 	surface *sdl.Surface
 
+	// Size of the last loaded ROM in bytes, and its contents as loaded so Reset() can load it again
+	romSize  int
+	romImage []byte
+
+	// Which memory addresses have been fetched as instructions, for coverage
+	executed []bool
+
+	// Number of cycles executed since the emulator was created
+	cycleCount uint64
+
+	// Snapshots of the machine state keyed by the cycle count they should be taken at
+	// A nil entry is a checkpoint that has been requested but not reached yet
+	checkpoints map[uint64][]byte
+
+	// When the status message currently shown in the window title should be cleared
+	statusExpiry time.Time
+
+	// Time source for pacing the main loop
+	clock clock
+
+	// Seeded source for Cxkk; nil means the unseeded global source
+	rng *rand.Rand
+
+	// Optional hook run on every fetched opcode that can rewrite or skip it
+	instructionFilter func(pc, opcode uint16) (uint16, bool)
+
+	// Subroutines currently being executed, innermost last, and how often each call has been made
+	callTrace  []uint16
+	callCounts map[callEdge]int
+
+	// Memory addresses written by the most recent cycle
+	stepWrites []uint16
+
+	// Error raised part way through the instruction being executed, returned by cycle() once it's done
+	fault error
+
+	// How many times each instruction pattern has been executed
+	opcodeCounts map[string]uint64
+
+	// User supplied handlers tried before the built-in decode
+	opcodeHandlers []opcodeHandler
+
+	// Optional cache of sprite rows read by Dxyn
+	spriteCacheEnabled bool
+	spriteCache        map[spriteKey][]byte
+	spritePages        map[int]map[spriteKey]bool
+
+	// Optional per-frame register dump
+	registerCSV *csv.Writer
+
+	// Instructions per second measured over the last sample, and where the current sample started
+	measuredIPS       float64
+	speedSampleStart  time.Time
+	speedSampleCycles uint64
+	speedOverlay      bool
+
+	// When the most recent frames were rendered, for the FPS average
+	frameTimes []time.Time
+
+	// Functions notified of lifecycle events
+	subscribers []func(Event)
+
+	// Area of the window surface the display is drawn into; anything outside of it is letterboxed
+	viewport sdl.Rect
+
 	// Settings
-	videoScale int
-	cycleDelay float64
+	videoScale     int
+	cycleDelay     float64
+	integerScaling bool
+	fullscreen     bool
+	strictJumps    bool
+	autoGrowMemory bool
+	maxRunTime     time.Duration
+	escapeDisabled bool
+	vfPoison       bool
+
+	// How Dxyn draws into each plane
+	drawModes [PLANE_COUNT]DrawMode
+
+	// When timer ticks are applied relative to the cycle they fall due with
+	timerOrder TimerDecrementOrder
+
+	// Instructions run per 60Hz frame, or 0 to run one instruction every cycle delay instead
+	cyclesPerFrame int
+
+	// Quirks where CHIP-8 interpreters disagree
+	incrementIndex bool
+	shiftUsesVy    bool
+	jumpUsesVx     bool
+
+	// The variant the ROM was written for, and the extension instructions already warned about under it
+	platform       Platform
+	platformWarned map[string]bool
+
+	// Apply the compatibility database's profile to ROMs as they're loaded
+	compatProfiles bool
+
+	// Keep the last frame on screen across resets instead of clearing it
+	preserveDisplay bool
+
+	// Addresses RunUntilBreak() stops at
+	breakpoints map[uint16]bool
+
+	// Set while the user or an embedder has paused execution; input and rendering carry on
+	paused bool
+
+	// Whether Pause() and Resume() release every held key, so nothing held across a pause reaches the program
+	clearKeysOnPause  bool
+	clearKeysOnResume bool
+
+	// Set once the program has jumped to itself
+	halted bool
+	onHalt HaltBehavior
+
+	// Set when Run() returned because maxRunTime ran out rather than because the user quit
+	timedOut bool
+
+	// Optional grid overlay drawn every gridSpacing pixels
+	gridOverlay bool
+	gridSpacing int
+
+	// Minimum number of frames a pixel stays rendered on once lit, and how many more frames each pixel has left in its last lit color
+	persistence   int
+	persistFrames [HIRES_VIDEO_WIDTH * HIRES_VIDEO_HEIGHT]int
+	persistColors [HIRES_VIDEO_WIDTH * HIRES_VIDEO_HEIGHT]uint32
+
+	// How much brightness an unlit pixel loses per frame (0 for no ghosting), and each pixel's brightness and last lit color
+	ghostDecay      float64
+	ghostBrightness [HIRES_VIDEO_WIDTH * HIRES_VIDEO_HEIGHT]float64
+	ghostColors     [HIRES_VIDEO_WIDTH * HIRES_VIDEO_HEIGHT]uint32
+
+	// Optional outline of the area covered by the last Dxyn, in display pixels
+	debugDrawBounds bool
+	lastDraw        *sdl.Rect
+
+	// Dxyn executions allowed per 60Hz frame, 0 for no limit, and how many the current frame has had
+	drawsPerFrame int
+	frameDraws    int
+
+	// Keys used to adjust the cycle delay while running
+	fasterKey sdl.Keycode
+	slowerKey sdl.Keycode
+
+	// How many times faster than the cycle delay or cycles per frame the ROM runs, and the keys that change it
+	speedMultiplier float64
+	speedUpKey      sdl.Keycode
+	speedDownKey    sdl.Keycode
+
+	// Audio device the beep is queued on (0 when none could be opened), and the square wave it plays
+	audioDevice     sdl.AudioDeviceID
+	audioSampleRate int
+	toneFrequency   float64
+	toneVolume      float64
+	tonePhase       float64
+
+	// Samples queued on the audio device less those dropped unplayed, and timer ticks spent beeping, for AudioDrift
+	audioSamples uint64
+	beepTicks    uint64
+
+	// Where StartAudioCapture writes to, and the samples captured so far
+	audioCapture   io.Writer
+	captureSamples []byte
+	capturePhase   float64
+
+	// Where frames are drawn, and whether SDL was skipped entirely
+	renderer Renderer
+	headless bool
+}
+
+// Wraps a failed SDL init with how to get SDL2, or how to run without it
+func sdlInitError(err error) error {
+	return fmt.Errorf("SDL2 not available (%w); install SDL2 as described in the README, or use -conformance to run a ROM without a window", err)
+}
+
+// Configures the emulator as NewChip8 creates it, failing creation if it returns an error
+type Option func(*chip8) error
+
+func NewChip8(videoScale int, cycleDelay float64, options ...Option) (*chip8, error) {
+	c8 := newCore(videoScale, cycleDelay)
+
+	for _, option := range options {
+		err := option(c8)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	c8.viewport = sdl.Rect{W: int32(VIDEO_WIDTH * c8.videoScale), H: int32(VIDEO_HEIGHT * c8.videoScale)}
+
+	if c8.headless {
+		return c8, nil
+	}
+
+	err := sdl.Init(sdl.INIT_EVERYTHING)
+	if err != nil {
+		return nil, sdlInitError(err)
+	}
+
+	window, err := sdl.CreateWindow(WINDOW_TITLE, sdl.WINDOWPOS_UNDEFINED, sdl.WINDOWPOS_UNDEFINED, int32(VIDEO_WIDTH*c8.videoScale), int32(VIDEO_HEIGHT*c8.videoScale), sdl.WINDOW_SHOWN)
+	if err != nil {
+		return nil, err
+	}
+	c8.window = window
+
+	surface, err := window.GetSurface()
+	if err != nil {
+		return nil, err
+	}
+	c8.surface = surface
+	c8.renderer = windowRenderer{c8: c8}
+
+	// A missing audio device shouldn't keep ROMs from running, they just play silently
+	err = c8.openAudio()
+	if err != nil {
+		log.Println("cannot open audio device, sound is disabled:", err)
+	}
+
+	return c8, nil
 }
 
-func NewChip8(videoScale int, cycleDelay float64) (*chip8, error) {
+/*
+Builds the machine itself (memory, registers, fontset, timers) without touching SDL.
+Tooling that only needs to execute instructions, like the conformance runner, can use this directly.
+*/
+func newCore(videoScale int, cycleDelay float64) *chip8 {
 	c8 := chip8{
-		videoScale: videoScale,
-		cycleDelay: cycleDelay,
+		videoScale:     videoScale,
+		cycleDelay:     cycleDelay,
+		memory:         make([]byte, MEMORY_SIZE),
+		executed:       make([]bool, MEMORY_SIZE),
+		stack:          make([]uint16, DEFAULT_STACK_DEPTH),
+		callCounts:     make(map[callEdge]int),
+		platformWarned: make(map[string]bool),
+		opcodeCounts:   make(map[string]uint64),
+		checkpoints:    make(map[uint64][]byte),
+		clock:          realClock{},
+		keyMap:         DefaultKeyMap(),
+		buttonMap:      DefaultButtonMap(),
+		controllers:    make(map[sdl.JoystickID]*sdl.GameController),
+		fasterKey:      sdl.K_MINUS,
+		slowerKey:      sdl.K_EQUALS,
+
+		speedMultiplier: 1,
+		speedUpKey:      sdl.K_KP_PLUS,
+		speedDownKey:    sdl.K_KP_MINUS,
+
+		incrementIndex: true,
+		toneFrequency:  DEFAULT_TONE_FREQUENCY,
+		toneVolume:     DEFAULT_TONE_VOLUME,
+		planeColors:    DEFAULT_PLANE_COLORS,
+		renderer:       nullRenderer{},
 	}
 
+	c8.reset()
+
+	return &c8
+}
+
+/*
+Puts the machine back in its power-on state: cleared memory with the fontset loaded, registers, stack and timers zeroed,
+and a blank display unless the display is set to be preserved across resets.
+*/
+func (c8 *chip8) reset() {
 	for k := range c8.registers {
 		c8.registers[k] = 0
 	}
@@ -103,29 +492,34 @@ func NewChip8(videoScale int, cycleDelay float64) (*chip8, error) {
 	}
 
 	// Load fontset into memory
-	fontset := [80]byte{
-		0xF0, 0x90, 0x90, 0x90, 0xF0, // 0
-		0x20, 0x60, 0x20, 0x20, 0x70, // 1
-		0xF0, 0x10, 0xF0, 0x80, 0xF0, // 2
-		0xF0, 0x10, 0xF0, 0x10, 0xF0, // 3
-		0x90, 0x90, 0xF0, 0x10, 0x10, // 4
-		0xF0, 0x80, 0xF0, 0x10, 0xF0, // 5
-		0xF0, 0x80, 0xF0, 0x90, 0xF0, // 6
-		0xF0, 0x10, 0x20, 0x40, 0x40, // 7
-		0xF0, 0x90, 0xF0, 0x90, 0xF0, // 8
-		0xF0, 0x90, 0xF0, 0x10, 0xF0, // 9
-		0xF0, 0x90, 0xF0, 0x90, 0x90, // A
-		0xE0, 0x90, 0xE0, 0x90, 0xE0, // B
-		0xF0, 0x80, 0x80, 0x80, 0xF0, // C
-		0xE0, 0x90, 0x90, 0x90, 0xE0, // D
-		0xF0, 0x80, 0xF0, 0x80, 0xF0, // E
-		0xF0, 0x80, 0xF0, 0x80, 0x80, // F
-	}
-
 	for k, v := range fontset {
 		c8.memory[FONTSET_START_ADDRESS+uint(k)] = v
 	}
 
+	// Load the SUPER-CHIP big fontset into memory
+	bigFontset := [160]byte{
+		0xFF, 0xFF, 0xC3, 0xC3, 0xC3, 0xC3, 0xC3, 0xC3, 0xFF, 0xFF, // 0
+		0x18, 0x78, 0x78, 0x18, 0x18, 0x18, 0x18, 0x18, 0xFF, 0xFF, // 1
+		0xFF, 0xFF, 0x03, 0x03, 0xFF, 0xFF, 0xC0, 0xC0, 0xFF, 0xFF, // 2
+		0xFF, 0xFF, 0x03, 0x03, 0xFF, 0xFF, 0x03, 0x03, 0xFF, 0xFF, // 3
+		0xC3, 0xC3, 0xC3, 0xC3, 0xFF, 0xFF, 0x03, 0x03, 0x03, 0x03, // 4
+		0xFF, 0xFF, 0xC0, 0xC0, 0xFF, 0xFF, 0x03, 0x03, 0xFF, 0xFF, // 5
+		0xFF, 0xFF, 0xC0, 0xC0, 0xFF, 0xFF, 0xC3, 0xC3, 0xFF, 0xFF, // 6
+		0xFF, 0xFF, 0x03, 0x03, 0x06, 0x0C, 0x18, 0x18, 0x18, 0x18, // 7
+		0xFF, 0xFF, 0xC3, 0xC3, 0xFF, 0xFF, 0xC3, 0xC3, 0xFF, 0xFF, // 8
+		0xFF, 0xFF, 0xC3, 0xC3, 0xFF, 0xFF, 0x03, 0x03, 0xFF, 0xFF, // 9
+		0x7E, 0xFF, 0xC3, 0xC3, 0xC3, 0xFF, 0xFF, 0xC3, 0xC3, 0xC3, // A
+		0xFC, 0xFC, 0xC3, 0xC3, 0xFC, 0xFC, 0xC3, 0xC3, 0xFC, 0xFC, // B
+		0x3C, 0xFF, 0xC3, 0xC0, 0xC0, 0xC0, 0xC0, 0xC3, 0xFF, 0x3C, // C
+		0xFC, 0xFE, 0xC3, 0xC3, 0xC3, 0xC3, 0xC3, 0xC3, 0xFE, 0xFC, // D
+		0xFF, 0xFF, 0xC0, 0xC0, 0xFF, 0xFF, 0xC0, 0xC0, 0xFF, 0xFF, // E
+		0xFF, 0xFF, 0xC0, 0xC0, 0xFF, 0xFF, 0xC0, 0xC0, 0xC0, 0xC0, // F
+	}
+
+	for k, v := range bigFontset {
+		c8.memory[BIG_FONTSET_START_ADDRESS+uint(k)] = v
+	}
+
 	for k := range c8.stack {
 		c8.stack[k] = 0
 	}
@@ -138,262 +532,1408 @@ func NewChip8(videoScale int, cycleDelay float64) (*chip8, error) {
 
 	c8.programCounter = uint16(START_ADDRESS)
 
-	err := sdl.Init(sdl.INIT_EVERYTHING)
-	if err != nil {
-		return nil, err
+	for k := range c8.executed {
+		c8.executed[k] = false
 	}
-
-	window, err := sdl.CreateWindow(WINDOW_TITLE, sdl.WINDOWPOS_UNDEFINED, sdl.WINDOWPOS_UNDEFINED, int32(VIDEO_WIDTH*c8.videoScale), int32(VIDEO_HEIGHT*c8.videoScale), sdl.WINDOW_SHOWN)
-	if err != nil {
-		return nil, err
+	c8.romSize = 0
+	c8.halted = false
+	clear(c8.keyWaitHeld[:])
+	c8.lastDraw = nil
+	c8.callTrace = nil
+	clear(c8.callCounts)
+	clear(c8.platformWarned)
+	c8.clearSpriteCache()
+	c8.planeMask = DEFAULT_PLANE_MASK
+
+	if !c8.preserveDisplay {
+		c8.hires = false
+		c8.clearAllPlanes()
 	}
-	c8.window = window
 
-	surface, err := window.GetSurface()
-	if err != nil {
-		return nil, err
-	}
-	c8.surface = surface
+	c8.emit(EventReset)
+}
 
-	c8.op00E0()
+/*
+Puts the machine back in its power-on state while keeping the window, audio and settings as they are.
+With reloadROM set the last loaded ROM is loaded again, as it was before it ran, so it starts over from scratch.
+*/
+func (c8 *chip8) Reset(reloadROM bool) error {
+	c8.reset()
+
+	if reloadROM && c8.romImage != nil {
+		return c8.loadROM(c8.romImage)
+	}
 
-	return &c8, nil
+	return nil
 }
 
+// Releases the audio device, controllers and window and shuts SDL down. Safe to call more than once, and on machines that never opened a window
 func (c8 *chip8) Destroy() {
+	if c8.window == nil {
+		return
+	}
+
+	c8.closeAudio()
+	c8.closeControllers()
+
+	// The surface belongs to the window and is freed along with it
+	c8.surface = nil
 	c8.window.Destroy()
+	c8.window = nil
+
 	sdl.Quit()
 }
 
-func (c8 *chip8) LoadChip8ROM(filepath string) error {
-	file, err := os.Open(filepath)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
+/*
+Makes the window user-resizable and, on resize, snaps the display to the largest integer multiple of 64x32 that fits.
+Whatever is left over is letterboxed so pixels always stay square and evenly sized.
+*/
+func (c8 *chip8) SetIntegerScaling(enabled bool) {
+	c8.integerScaling = enabled
+
+	if c8.window != nil {
+		c8.window.SetResizable(enabled)
+	}
+}
+
+/*
+Runs a fixed number of instructions per 60Hz frame, ticking the timers and drawing once after each frame's worth,
+so the speed no longer depends on how long each loop iteration takes. 500 to 1000 suits most games.
+0 goes back to running one instruction per cycle delay.
+*/
+func (c8 *chip8) SetCyclesPerFrame(cycles int) {
+	c8.cyclesPerFrame = max(cycles, 0)
+}
+
+/*
+Switches the window to and from fullscreen at the desktop resolution. The display is scaled up by the largest integer
+multiple that fits and letterboxed, the same as a resized window with integer scaling. Headless emulators only
+record the setting.
+*/
+func (c8 *chip8) SetFullscreen(enabled bool) error {
+	c8.fullscreen = enabled
+
+	if c8.window == nil {
+		return nil
+	}
+
+	var flags uint32 = 0
+	if enabled {
+		flags = sdl.WINDOW_FULLSCREEN_DESKTOP
+	}
+
+	err := c8.window.SetFullscreen(flags)
+	if err != nil {
+		return err
+	}
+
+	// The size change event will follow, but the surface must be replaced before the next frame is drawn
+	c8.resize(c8.window.GetSize())
+
+	return nil
+}
+
+// Reports whether the window is fullscreen
+func (c8 *chip8) Fullscreen() bool {
+	return c8.fullscreen
+}
+
+/*
+Sets how many nested subroutine calls the stack can hold, between 1 and MAX_STACK_DEPTH.
+Entries already on the stack are kept, so the depth can't be reduced below the current stack pointer.
+*/
+func (c8 *chip8) SetStackDepth(depth int) error {
+	if depth < 1 || depth > MAX_STACK_DEPTH {
+		return fmt.Errorf("stack depth %d is outside of 1-%d", depth, MAX_STACK_DEPTH)
+	}
+
+	if depth < int(c8.stackPointer) {
+		return fmt.Errorf("stack depth %d is below the %d entries currently on the stack", depth, c8.stackPointer)
+	}
+
+	stack := make([]uint16, depth)
+	copy(stack, c8.stack)
+	c8.stack = stack
+
+	return nil
+}
+
+// Controls whether resetting the machine clears the display (the default) or leaves the last frame up for debugging
+func (c8 *chip8) SetClearDisplayOnReset(enabled bool) {
+	c8.preserveDisplay = !enabled
+}
+
+/*
+Chooses whether timer ticks that fall due in the same frame as a cycle are applied before or after it executes.
+This decides whether a ROM that sets the delay timer and reads it straight back sees it already decremented.
+*/
+func (c8 *chip8) SetTimerDecrementOrder(order TimerDecrementOrder) {
+	c8.timerOrder = order
+}
+
+// Seeds the random source used by Cxkk so runs are reproducible
+func (c8 *chip8) SetRandomSeed(seed uint64) {
+	c8.rng = rand.New(rand.NewPCG(seed, seed))
+}
+
+/*
+Controls whether Fx55/Fx65 leave I pointing past the last register stored or loaded (I += x + 1), as the COSMAC VIP did.
+SUPER-CHIP leaves I unchanged. Enabled by default, since that's what most test ROMs expect.
+*/
+func (c8 *chip8) SetIncrementIndexOnLoadStore(enabled bool) {
+	c8.incrementIndex = enabled
+}
+
+/*
+When enabled, 8xy6/8xyE copy Vy into Vx before shifting, as the original CHIP-8 did.
+Disabled by default, which shifts Vx in place and ignores Vy like SUPER-CHIP.
+*/
+func (c8 *chip8) SetShiftUsesVy(enabled bool) {
+	c8.shiftUsesVy = enabled
+}
+
+/*
+When enabled, Bnnn is read as SUPER-CHIP's Bxnn and jumps to xnn + Vx instead of nnn + V0.
+Disabled by default; only SUPER-CHIP ROMs that use the jump (Blinky and other SCHIP ports) need it, classic CHIP-8 ROMs expect V0.
+*/
+func (c8 *chip8) SetBxnnJumpQuirk(enabled bool) {
+	c8.jumpUsesVx = enabled
+}
+
+/*
+Grows memory to XO-CHIP's 64k, or shrinks it back to 4k, keeping whatever fits of the current contents.
+Call it before loading a ROM that's larger than 4k or that uses F000 NNNN, which is only decoded with extended memory
+and is otherwise an unknown opcode.
+*/
+func (c8 *chip8) SetExtendedMemory(enabled bool) {
+	size := MEMORY_SIZE
+	if enabled {
+		size = EXTENDED_MEMORY_SIZE
+	}
+
+	memory := make([]byte, size)
+	copy(memory, c8.memory)
+	c8.memory = memory
+
+	executed := make([]bool, size)
+	copy(executed, c8.executed)
+	c8.executed = executed
+
+	if c8.romSize > size-int(START_ADDRESS) {
+		c8.romSize = size - int(START_ADDRESS)
+	}
+	c8.clearSpriteCache()
+}
+
+/*
+When enabled, a 4k machine that executes XO-CHIP's F000 NNNN switches to extended memory on the spot, keeping its
+contents, instead of failing with ErrUnknownOpcode. Lets XO-CHIP ROMs run without knowing what they are up front.
+*/
+func (c8 *chip8) SetAutoGrowMemory(enabled bool) {
+	c8.autoGrowMemory = enabled
+}
+
+// Reports whether memory has been extended to XO-CHIP's 64k
+func (c8 *chip8) extendedMemory() bool {
+	return len(c8.memory) == EXTENDED_MEMORY_SIZE
+}
+
+/*
+Skips the instruction after a conditional skip. With extended memory F000 NNNN is four bytes long, so skipping it
+steps over its address as well, as XO-CHIP does.
+*/
+func (c8 *chip8) skipInstruction() {
+	if c8.extendedMemory() && c8.peekOpcode() == 0xF000 {
+		c8.programCounter += 2
+	}
+
+	c8.programCounter += 2
+}
+
+// When enabled, a Bnnn jump that would escape memory fails with ErrOutOfBounds instead of being masked
+func (c8 *chip8) SetStrictJumps(enabled bool) {
+	c8.strictJumps = enabled
+}
+
+// Controls whether Escape quits the emulator; closing the window always quits
+func (c8 *chip8) SetEscapeQuits(enabled bool) {
+	c8.escapeDisabled = !enabled
+}
+
+/*
+When enabled, VF is set to VF_POISON after operations that leave it undefined (8xy1, 8xy2, 8xy3).
+ROMs that wrongly rely on VF surviving those operations then misbehave visibly instead of by luck.
+*/
+func (c8 *chip8) SetVFPoison(enabled bool) {
+	c8.vfPoison = enabled
+}
+
+// Poisons VF after an operation on Vx that doesn't define it, unless the operation wrote VF itself
+func (c8 *chip8) poisonVF(vx byte) {
+	if c8.vfPoison && vx != 0xF {
+		c8.registers[0xF] = VF_POISON
+	}
+}
+
+// Selects how Dxyn combines sprites with the display in every plane, XOR by default
+func (c8 *chip8) SetDrawMode(mode DrawMode) {
+	for p := range c8.drawModes {
+		c8.drawModes[p] = mode
+	}
+}
+
+/*
+Selects how Dxyn combines sprites with a single plane, so one plane can be drawn with OR, say, while the other
+keeps XOR and its collisions. Only XOR planes set VF.
+*/
+func (c8 *chip8) SetPlaneDrawMode(plane int, mode DrawMode) error {
+	if plane < 0 || plane >= PLANE_COUNT {
+		return fmt.Errorf("plane %d out of range, there are %d", plane, PLANE_COUNT)
+	}
+
+	c8.drawModes[plane] = mode
+	return nil
+}
+
+/*
+Sets the keys that adjust the cycle delay while running.
+Defaults are - to run faster (shorter delay) and = (the unshifted + key) to run slower (longer delay).
+*/
+func (c8 *chip8) SetCycleDelayKeys(faster sdl.Keycode, slower sdl.Keycode) {
+	c8.fasterKey = faster
+	c8.slowerKey = slower
+}
+
+// Adjusts the cycle delay by the given amount, keeping it within bounds, and briefly shows the new value
+func (c8 *chip8) adjustCycleDelay(delta float64) {
+	c8.cycleDelay = min(max(c8.cycleDelay+delta, MIN_CYCLE_DELAY), MAX_CYCLE_DELAY)
+	c8.showStatus(fmt.Sprintf("cycle delay %.0fms", c8.cycleDelay))
+}
+
+// Shows a message in the window title for STATUS_DURATION
+func (c8 *chip8) showStatus(message string) {
+	if c8.window == nil {
+		return
+	}
+
+	c8.window.SetTitle(WINDOW_TITLE + " - " + message)
+	c8.statusExpiry = c8.clock.Now().Add(STATUS_DURATION)
+}
+
+// Restores the window title once the status message has been shown long enough
+func (c8 *chip8) clearExpiredStatus() {
+	if !c8.statusExpiry.IsZero() && c8.clock.Now().After(c8.statusExpiry) {
+		c8.window.SetTitle(WINDOW_TITLE)
+		c8.statusExpiry = time.Time{}
+	}
+}
+
+/*
+Draws a grid over the display every spacing pixels (8 matches the width of a sprite) to help line up sprites.
+The grid is only drawn in the render pass and never touches the pixel buffer.
+*/
+func (c8 *chip8) SetGridOverlay(enabled bool, spacing int) {
+	if spacing < 1 {
+		spacing = 8
+	}
+
+	c8.gridOverlay = enabled
+	c8.gridSpacing = spacing
+}
+
+/*
+Keeps any pixel that turns on rendered for at least the given number of frames, even if it's XORed off sooner.
+This hides the flicker of games that erase and redraw sprites every frame, like Brix. 0 or 1 turns it off.
+Only what's rendered is affected; the pixel buffer and collisions are untouched.
+*/
+func (c8 *chip8) SetPersistence(frames int) {
+	c8.persistence = frames
+	clear(c8.persistFrames[:])
+}
+
+/*
+Fades pixels out over a few frames once they turn off instead of dropping them straight to the background, which
+smooths out XOR flicker. decay is the share of full brightness lost each frame, so 0.25 fades over four frames;
+0 turns ghosting off. Like persistence, only what's rendered is affected.
+*/
+func (c8 *chip8) SetGhosting(decay float64) {
+	c8.ghostDecay = min(max(decay, 0), 1)
+	clear(c8.ghostBrightness[:])
+}
+
+/*
+Allows at most n Dxyn executions per 60Hz frame; once they're used up the next Dxyn stalls, re-executing until the
+timers tick into a new frame, as the COSMAC VIP did waiting for vertical blank. Spreading out the draws of ROMs that
+throw many sprites at each frame smooths their flicker. 0 removes the limit.
+*/
+func (c8 *chip8) SetDrawsPerFrame(n int) {
+	c8.drawsPerFrame = max(n, 0)
+	c8.frameDraws = 0
+}
+
+// Outlines the last sprite drawn in the render pass, without touching the pixel buffer
+func (c8 *chip8) SetDebugDrawBounds(enabled bool) {
+	c8.debugDrawBounds = enabled
+}
+
+// Picks the largest integer scale that fits the window and centers the display within it
+func integerScale(winWidth int32, winHeight int32) (int32, sdl.Rect) {
+	scale := min(winWidth/VIDEO_WIDTH, winHeight/VIDEO_HEIGHT)
+	if scale < 1 {
+		scale = 1
+	}
+
+	w := VIDEO_WIDTH * scale
+	h := VIDEO_HEIGHT * scale
+
+	return scale, sdl.Rect{X: (winWidth - w) / 2, Y: (winHeight - h) / 2, W: w, H: h}
+}
+
+// The window surface is invalidated on resize, so grab the new one along with the new scale and viewport
+func (c8 *chip8) resize(winWidth int32, winHeight int32) {
+	surface, err := c8.window.GetSurface()
+	if err != nil {
+		log.Println("cannot get window surface after resize:", err)
+		return
+	}
+	c8.surface = surface
+
+	scale, viewport := integerScale(winWidth, winHeight)
+	c8.videoScale = int(scale)
+	c8.viewport = viewport
+}
+
+func (c8 *chip8) LoadChip8ROM(filepath string) error {
+	file, err := os.Open(filepath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return c8.LoadROM(file)
+}
+
+// Loads a ROM read from r, for ROMs that are embedded, generated or fetched rather than stored as a file
+func (c8 *chip8) LoadROM(r io.Reader) error {
+	buffer, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	return c8.loadROM(buffer)
+}
+
+// Loads a ROM that's already in memory. The bytes are copied, so b can be reused afterwards
+func (c8 *chip8) LoadROMBytes(b []byte) error {
+	return c8.loadROM(b)
+}
+
+// Load the ROM contents into the Chip8's memory, starting at 0x200
+func (c8 *chip8) loadROM(buffer []byte) error {
+	available := len(c8.memory) - int(START_ADDRESS)
+	if len(buffer) > available {
+		return fmt.Errorf("ROM is %d bytes, larger than the %d bytes available from 0x%03X", len(buffer), available, START_ADDRESS)
+	}
+
+	for i, b := range buffer {
+		c8.memory[int(START_ADDRESS)+i] = b
+	}
+	c8.romSize = len(buffer)
+	c8.romImage = slices.Clone(buffer)
+	c8.clearSpriteCache()
+	c8.halted = false
+
+	if c8.compatProfiles {
+		if profile, ok := LookupCompatibility(ROMID(buffer)); ok {
+			c8.ApplyProfile(profile)
+		}
+	}
+
+	c8.emit(EventROMLoaded)
+
+	return nil
+}
+
+// Returns a copy of the loaded ROM as it currently sits in memory, from the start address for the loaded length
+func (c8 *chip8) LoadedROM() []byte {
+	rom := make([]byte, c8.romSize)
+	copy(rom, c8.memory[START_ADDRESS:])
+
+	return rom
+}
+
+// Returns the size in bytes of the loaded ROM
+func (c8 *chip8) ROMSize() int {
+	return c8.romSize
+}
+
+/*
+Places data in memory starting at addr, for overlay and bootloader experiments.
+The program counter is left alone; use SetProgramCounter to start executing from addr.
+*/
+func (c8 *chip8) LoadROMAt(data []byte, addr uint16) error {
+	if int(addr)+len(data) > len(c8.memory) {
+		return fmt.Errorf("%d bytes at 0x%04X would run past the end of memory (0x%04X)", len(data), addr, len(c8.memory)-1)
+	}
+
+	for i, b := range data {
+		c8.writeMemory(addr+uint16(i), b)
+	}
+
+	return nil
+}
+
+// Sets the address of the next instruction to execute
+func (c8 *chip8) SetProgramCounter(addr uint16) error {
+	if int(addr)+1 >= len(c8.memory) {
+		return fmt.Errorf("program counter 0x%04X is outside of memory", addr)
+	}
+
+	c8.programCounter = addr
+
+	return nil
+}
+
+// Writes a byte to memory, recording the write for the current step and dropping any cached sprites covering that address
+func (c8 *chip8) writeMemory(address uint16, value byte) {
+	if int(address) >= len(c8.memory) {
+		c8.fail(fmt.Errorf("%w: write to 0x%04X", ErrOutOfBounds, address))
+		return
+	}
+
+	c8.memory[address] = value
+	c8.stepWrites = append(c8.stepWrites, address)
+	c8.invalidateSprites(int(address))
+}
+
+func (c8 *chip8) processInput() bool {
+	quit := false
+
+	// Headless runs never initialised SDL, so there are no events to poll
+	if c8.headless {
+		return quit
+	}
+
+	for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
+		switch t := event.(type) {
+		case *sdl.QuitEvent:
+			quit = true
+		case *sdl.WindowEvent:
+			if t.Event == sdl.WINDOWEVENT_SIZE_CHANGED && (c8.integerScaling || c8.fullscreen) {
+				c8.resize(t.Data1, t.Data2)
+			}
+		case *sdl.ControllerDeviceEvent:
+			switch t.Type {
+			case sdl.CONTROLLERDEVICEADDED:
+				c8.openController(int(t.Which))
+			case sdl.CONTROLLERDEVICEREMOVED:
+				c8.closeController(t.Which)
+			}
+		case *sdl.ControllerButtonEvent:
+			c8.pressButton(sdl.GameControllerButton(t.Button), t.Type == sdl.CONTROLLERBUTTONDOWN)
+		case *sdl.KeyboardEvent:
+			if c8.handleKey(t) {
+				quit = true
+			}
+		}
+	}
+
+	return quit
+}
+
+// Applies a key press or release: hotkeys, Escape and the keypad. Returns true if the key asks to quit
+func (c8 *chip8) handleKey(t *sdl.KeyboardEvent) bool {
+	quit := false
+
+	var s byte = 0
+	if t.Type == sdl.KEYDOWN {
+		s = 1
+	}
+
+	if s == 1 {
+		switch t.Keysym.Sym {
+		case c8.fasterKey:
+			c8.adjustCycleDelay(-CYCLE_DELAY_STEP)
+		case c8.slowerKey:
+			c8.adjustCycleDelay(CYCLE_DELAY_STEP)
+		case c8.speedUpKey:
+			c8.adjustSpeedMultiplier(SPEED_MULTIPLIER_STEP)
+		case c8.speedDownKey:
+			c8.adjustSpeedMultiplier(1 / SPEED_MULTIPLIER_STEP)
+		case sdl.K_p:
+			// Holding P shouldn't flip between paused and running on every repeat
+			if t.Repeat == 0 {
+				c8.togglePause()
+			}
+		case sdl.K_F11:
+			if t.Repeat == 0 {
+				err := c8.SetFullscreen(!c8.fullscreen)
+				if err != nil {
+					log.Println("cannot toggle fullscreen:", err)
+				}
+			}
+		case sdl.K_F12:
+			if t.Repeat == 0 {
+				c8.saveScreenshot()
+			}
+		}
+	}
+
+	switch t.Keysym.Sym {
+	case sdl.K_ESCAPE:
+		if s == 1 && !c8.escapeDisabled {
+			quit = true
+		}
+	}
+
+	if key, ok := c8.keyMap[t.Keysym.Sym]; ok {
+		// Key repeats don't change the state, so only transitions are logged
+		if c8.inputLogEnabled && c8.keypad[key] != s {
+			c8.logInput(key, s == 1)
+		}
+
+		c8.keypad[key] = s
+	}
+
+	return quit
+}
+
+/*
+Installs a hook called with the address and opcode of every instruction after it's fetched. The returned opcode is
+executed in place of the fetched one, or nothing is executed if skip is true. A skipped instruction is stepped over
+the same way a conditional skip would, so F000 NNNN's address goes with it under extended memory. Pass nil to remove the hook.
+*/
+func (c8 *chip8) SetInstructionFilter(filter func(pc, opcode uint16) (newOpcode uint16, skip bool)) {
+	c8.instructionFilter = filter
+}
+
+/*
+When we talk about one cycle of this primitive CPU that we're emulating, we're talking about it doing three things:
+- Fetch the next instruction in the form of an opcode
+- Decode the instruction to determine what operation needs to occur
+- Execute the instruction
+*/
+func (c8 *chip8) cycle() error {
+	c8.stateLock.Lock()
+	defer c8.stateLock.Unlock()
+
+	c8.stepWrites = c8.stepWrites[:0]
+	c8.fault = nil
+
+	// Jumping to the last byte of memory or running off the end leaves no whole instruction to fetch
+	if int(c8.programCounter)+1 >= len(c8.memory) {
+		c8.emit(EventCrash)
+		return fmt.Errorf("%w: program counter 0x%04X has no instruction to fetch", ErrOutOfBounds, c8.programCounter)
+	}
+
+	// Fetch
+	pc := c8.programCounter
+	c8.opcode = c8.peekOpcode()
+	c8.executed[c8.programCounter] = true
+	c8.executed[c8.programCounter+1] = true
+
+	// Increment the PC before we execute anything
+	c8.programCounter += 2
+
+	skip := false
+	if c8.instructionFilter != nil {
+		c8.opcode, skip = c8.instructionFilter(c8.programCounter-2, c8.opcode)
+	}
+
+	if skip {
+		c8.programCounter = pc
+		c8.skipInstruction()
+	} else {
+		c8.checkPlatform(pc)
+		c8.countOpcode()
+
+		err := c8.execute()
+		if err == nil && c8.fault != nil {
+			err = fmt.Errorf("%w at 0x%04X", c8.fault, pc)
+		}
+
+		if err != nil {
+			c8.emit(EventCrash)
+			return err
+		}
+	}
+
+	c8.cycleCount++
+
+	if snapshot, ok := c8.checkpoints[c8.cycleCount]; ok && snapshot == nil {
+		c8.checkpoints[c8.cycleCount] = c8.snapshot()
+	}
+
+	return nil
+}
+
+// Decrements the timers once; called at 60Hz independently of how fast instructions run
+func (c8 *chip8) tickTimers() {
+	// Timer ticks mark the frames the draw budget is counted over
+	c8.frameDraws = 0
+
+	if c8.audioCapture != nil {
+		c8.captureTick()
+	}
+
+	// Decrement the delay timer if it's been set
+	if c8.delayTimer > 0 {
+		c8.delayTimer -= 1
+	}
+
+	// Decrement the sound timer if it's been set
+	if c8.soundTimer > 0 {
+		c8.soundTimer -= 1
+		c8.beepTicks++
+	}
+}
+
+/*
+Runs a cycle outside of Run(), where there's no wall clock to pace the timers. The timers are ticked every
+HEADLESS_CYCLES_PER_TICK cycles instead, which keeps tooling runs deterministic.
+*/
+func (c8 *chip8) headlessCycle() error {
+	tick := (c8.cycleCount+1)%HEADLESS_CYCLES_PER_TICK == 0
+
+	if tick && c8.timerOrder == TimersBeforeCycle {
+		c8.tickTimers()
+	}
+
+	err := c8.cycle()
+	if err != nil {
+		return err
+	}
+
+	if tick && c8.timerOrder == TimersAfterCycle {
+		c8.tickTimers()
+	}
+
+	return nil
+}
+
+func (c8 *chip8) tickTimersN(n int) {
+	for range n {
+		c8.tickTimers()
+	}
+}
+
+// Returned, wrapped with the opcode and its address, when a fetched instruction can't be decoded
+var ErrUnknownOpcode = errors.New("unknown opcode")
+
+// Returned, wrapped with the address involved and the instruction's address, when an instruction reaches past the end of memory
+var ErrOutOfBounds = errors.New("address out of bounds")
+
+// Returned when 2nnn would nest deeper than the stack holds, or 00EE returns with nothing on the stack
+var ErrStackOverflow = errors.New("stack overflow")
+var ErrStackUnderflow = errors.New("stack underflow")
+
+// Decode and Execute the fetched opcode
+func (c8 *chip8) execute() error {
+	if c8.runOpcodeHandler() {
+		return nil
+	}
+
+	switch c8.opcode & 0xF000 {
+	case 0x0000:
+		switch c8.opcode & 0x00FF {
+		case 0x00E0:
+			c8.op00E0()
+		case 0x00EE:
+			c8.op00EE()
+		case 0x00FB:
+			c8.op00FB()
+		case 0x00FC:
+			c8.op00FC()
+		case 0x00FE:
+			c8.op00FE()
+		case 0x00FF:
+			c8.op00FF()
+		default:
+			if c8.opcode&0x00F0 == 0x00C0 {
+				c8.op00Cn()
+			}
+		}
+	case 0x1000:
+		c8.op1nnn()
+	case 0x2000:
+		c8.op2nnn()
+	case 0x3000:
+		c8.op3xkk()
+	case 0x4000:
+		c8.op4xkk()
+	case 0x5000:
+		c8.op5xy0()
+	case 0x6000:
+		c8.op6xkk()
+	case 0x7000:
+		c8.op7xkk()
+	case 0x8000:
+		switch c8.opcode & 0x000F {
+		case 0x0000:
+			c8.op8xy0()
+		case 0x0001:
+			c8.op8xy1()
+		case 0x0002:
+			c8.op8xy2()
+		case 0x0003:
+			c8.op8xy3()
+		case 0x0004:
+			c8.op8xy4()
+		case 0x0005:
+			c8.op8xy5()
+		case 0x0006:
+			c8.op8xy6()
+		case 0x0007:
+			c8.op8xy7()
+		case 0x000E:
+			c8.op8xyE()
+		default:
+			return c8.unknownOpcode()
+		}
+	case 0x9000:
+		c8.op9xy0()
+	case 0xA000:
+		c8.opAnnn()
+	case 0xB000:
+		c8.opBnnn()
+	case 0xC000:
+		c8.opCxkk()
+	case 0xD000:
+		c8.opDxyn()
+	case 0xE000:
+		switch c8.opcode & 0x000F {
+		case 0x0001:
+			c8.opExA1()
+		case 0x000E:
+			c8.opEx9E()
+		default:
+			return c8.unknownOpcode()
+		}
+	case 0xF000:
+		switch c8.opcode & 0x00FF {
+		case 0x0000:
+			if c8.opcode != 0xF000 {
+				return c8.unknownOpcode()
+			}
+
+			// A ROM that turns out to be XO-CHIP can have memory grown under it the first time it needs to
+			if c8.autoGrowMemory && !c8.extendedMemory() {
+				c8.SetExtendedMemory(true)
+			}
+
+			// The long load only exists with XO-CHIP's extended memory; on a 4k machine it's as unknown as any other
+			if !c8.extendedMemory() {
+				return c8.unknownOpcode()
+			}
+			c8.opF000()
+		case 0x0001:
+			c8.opFn01()
+		case 0x0007:
+			c8.opFx07()
+		case 0x000A:
+			c8.opFx0A()
+		case 0x0015:
+			c8.opFx15()
+		case 0x0018:
+			c8.opFx18()
+		case 0x001E:
+			c8.opFx1E()
+		case 0x0029:
+			c8.opFx29()
+		case 0x0030:
+			c8.opFx30()
+		case 0x0033:
+			c8.opFx33()
+		case 0x0055:
+			c8.opFx55()
+		case 0x0065:
+			c8.opFx65()
+		default:
+			return c8.unknownOpcode()
+		}
+	default:
+		return c8.unknownOpcode()
+	}
+
+	return nil
+}
+
+// Reports the fetched instruction as one that can't be decoded
+func (c8 *chip8) unknownOpcode() error {
+	return fmt.Errorf("%w 0x%04X at 0x%04X", ErrUnknownOpcode, c8.opcode, c8.programCounter-2)
+}
+
+/*
+Fills a rect on the window surface with a color given as 0xAARRGGBB.
+The surface's pixel format decides the byte order FillRect expects, which varies between platforms and isn't
+necessarily the native layout of a uint32, so the channels are packed for that format with MapRGBA.
+*/
+func (c8 *chip8) fillRect(rect *sdl.Rect, argb uint32) {
+	r, g, b, a := argbChannels(argb)
+	color := sdl.MapRGBA(c8.surface.Format, r, g, b, a)
+	c8.surface.FillRect(rect, color)
+}
+
+// Splits a 0xAARRGGBB color into its channels, in the order MapRGBA takes them
+func argbChannels(argb uint32) (r, g, b, a uint8) {
+	return uint8(argb >> 16), uint8(argb >> 8), uint8(argb), uint8(argb >> 24)
+}
+
+// Update the display
+func (c8 *chip8) update() {
+	c8.stateLock.Lock()
+	defer c8.stateLock.Unlock()
+
+	// Clear surface
+	c8.renderer.Clear()
+
+	// Draw on the surface
+	width, height := c8.Resolution()
+	for k := range width * height {
+		col := int32(k % width)
+		row := int32(k / width)
+		color := c8.pixelColor(k)
+
+		if c8.persistence > 1 {
+			color = c8.persist(k, color)
+		}
+
+		if c8.ghostDecay > 0 {
+			color = c8.ghost(k, color)
+		}
+
+		xPos, yPos := c8.windowPoint(col, row)
+		xEnd, yEnd := c8.windowPoint(col+1, row+1)
+		pixel := sdl.Rect{X: xPos, Y: yPos, W: xEnd - xPos, H: yEnd - yPos}
+
+		c8.renderer.Draw(pixel, color)
+	}
+
+	if c8.gridOverlay {
+		c8.drawGrid()
+	}
+
+	if c8.debugDrawBounds && c8.lastDraw != nil {
+		c8.drawLastDrawBounds()
+	}
+
+	if c8.speedOverlay {
+		c8.drawSpeedOverlay()
+	}
+
+	c8.renderer.Present()
+}
+
+/*
+Keeps a pixel rendered on, in the color it was last lit in, until it has been shown for the configured number of
+frames, even if it was XORed off sooner
+*/
+func (c8 *chip8) persist(k int, color uint32) uint32 {
+	if color != c8.planeColors[0] {
+		c8.persistFrames[k] = c8.persistence - 1
+		c8.persistColors[k] = color
+		return color
+	}
+
+	if c8.persistFrames[k] > 0 {
+		c8.persistFrames[k]--
+		return c8.persistColors[k]
+	}
+
+	return color
+}
+
+/*
+Converts a display pixel coordinate to window coordinates within the viewport.
+The viewport is sized for the low-res display, so in high-res mode each pixel covers half as much of it.
+*/
+func (c8 *chip8) windowPoint(x int32, y int32) (int32, int32) {
+	width, height := c8.Resolution()
+
+	return c8.viewport.X + x*c8.viewport.W/int32(width), c8.viewport.Y + y*c8.viewport.H/int32(height)
+}
+
+// Renders a pixel that has turned off in its last lit color dimmed by how far it has faded, until it fades out completely
+func (c8 *chip8) ghost(k int, color uint32) uint32 {
+	if color != c8.planeColors[0] {
+		c8.ghostBrightness[k] = 1
+		c8.ghostColors[k] = color
+		return color
+	}
+
+	c8.ghostBrightness[k] = max(c8.ghostBrightness[k]-c8.ghostDecay, 0)
+	if c8.ghostBrightness[k] == 0 {
+		return color
+	}
+
+	dim := func(channel uint32) uint32 {
+		return uint32(float64(channel&0xFF) * c8.ghostBrightness[k])
+	}
+
+	lit := c8.ghostColors[k]
+	return 0xFF000000 | dim(lit>>16)<<16 | dim(lit>>8)<<8 | dim(lit)
+}
+
+// Outlines the area covered by the last Dxyn, converted from display pixels to window coordinates
+func (c8 *chip8) drawLastDrawBounds() {
+	x, y := c8.windowPoint(c8.lastDraw.X, c8.lastDraw.Y)
+	xEnd, yEnd := c8.windowPoint(c8.lastDraw.X+c8.lastDraw.W, c8.lastDraw.Y+c8.lastDraw.H)
+	w := xEnd - x
+	h := yEnd - y
+
+	c8.renderer.Draw(sdl.Rect{X: x, Y: y, W: w, H: 1}, DEBUG_DRAW_COLOR)
+	c8.renderer.Draw(sdl.Rect{X: x, Y: y + h - 1, W: w, H: 1}, DEBUG_DRAW_COLOR)
+	c8.renderer.Draw(sdl.Rect{X: x, Y: y, W: 1, H: h}, DEBUG_DRAW_COLOR)
+	c8.renderer.Draw(sdl.Rect{X: x + w - 1, Y: y, W: 1, H: h}, DEBUG_DRAW_COLOR)
+}
+
+// Draws one pixel wide grid lines across the viewport at the configured spacing
+func (c8 *chip8) drawGrid() {
+	width, height := c8.Resolution()
+	step := c8.gridSpacing
+
+	for col := step; col < width; col += step {
+		x, _ := c8.windowPoint(int32(col), 0)
+		c8.renderer.Draw(sdl.Rect{X: x, Y: c8.viewport.Y, W: 1, H: c8.viewport.H}, GRID_COLOR)
+	}
+
+	for row := step; row < height; row += step {
+		_, y := c8.windowPoint(0, int32(row))
+		c8.renderer.Draw(sdl.Rect{X: c8.viewport.X, Y: y, W: c8.viewport.W, H: 1}, GRID_COLOR)
+	}
+}
+
+/*
+Our main loop that will call our cycle() receiver method continuously until exit, handle input, and render with SDL.
+
+With each iteration of the loop: input from the keyboard is parsed, the timers are ticked for every 1/60s that has
+passed, a cycle is run for every cycle delay that has passed since the last one, and the screen is updated. When
+nothing is due yet the loop sleeps until the next cycle or timer tick.
+
+Returns an error if the ROM hits an instruction that can't be executed.
+*/
+func (c8 *chip8) Run() error {
+	reason, err := c8.runFor(c8.maxRunTime)
+	c8.timedOut = reason == stopTimeout
+
+	return err
+}
+
+/*
+Runs one 60Hz frame: the configured number of cycles and a single timer tick, in the order set by
+SetTimerDecrementOrder. The rest of the frame is skipped once the program halts, since it would only jump in place.
+*/
+func (c8 *chip8) runFrame() error {
+	if c8.timerOrder == TimersBeforeCycle {
+		c8.tickTimers()
+	}
+
+	for range c8.frameCycles() {
+		if c8.halted {
+			break
+		}
+
+		err := c8.cycle()
+		if err != nil {
+			return err
+		}
+	}
+
+	if c8.timerOrder == TimersAfterCycle {
+		c8.tickTimers()
+	}
+
+	return nil
+}
+
+// Why runFor() returned
+type stopReason int
+
+const (
+	stopQuit stopReason = iota
+	stopTimeout
+	stopHalt
+	stopError
+)
+
+/*
+Runs the main loop until the user quits, the program halts with HaltExit set, an instruction fails, or limit has passed
+(zero meaning no limit). The error is only set when stopping with stopError.
+*/
+func (c8 *chip8) runFor(limit time.Duration) (stopReason, error) {
+	startTime := c8.clock.Now()
+	lastCycleTime := startTime
+	lastTimerTick := startTime
+
+	for {
+		if c8.processInput() {
+			return stopQuit, nil
+		}
+		c8.clearExpiredStatus()
+		c8.updateSpeedStats()
+
+		if limit > 0 && c8.clock.Since(startTime) >= limit {
+			return stopTimeout, nil
+		}
+
+		if c8.halted && c8.onHalt == HaltExit {
+			return stopHalt, nil
+		}
+
+		// Keep the frame up while paused, and start timing afresh on resume so nothing tries to catch up
+		if c8.paused {
+			c8.update()
+			c8.clock.Sleep(IDLE_POLL_INTERVAL)
+
+			lastCycleTime = c8.clock.Now()
+			lastTimerTick = lastCycleTime
+			continue
+		}
+
+		// Timers run at 60Hz no matter how many cycles fit in between, catching up if the loop fell behind
+		ticks := 0
+		for c8.clock.Since(lastTimerTick) >= TIMER_INTERVAL {
+			lastTimerTick = lastTimerTick.Add(TIMER_INTERVAL)
+			ticks++
+		}
+
+		// The program has stopped itself, so keep the last frame up and the timers and sound running, waking only once per tick
+		if c8.halted {
+			c8.tickTimersN(ticks)
+			c8.update()
+			c8.updateAudio()
+
+			c8.clock.Sleep(TIMER_INTERVAL - c8.clock.Since(lastTimerTick))
+			continue
+		}
+
+		// With a fixed number of cycles per frame, every 60Hz tick is a whole frame and the cycle delay isn't used
+		if c8.cyclesPerFrame > 0 {
+			if ticks == 0 {
+				c8.clock.Sleep(TIMER_INTERVAL - c8.clock.Since(lastTimerTick))
+				continue
+			}
+
+			for range ticks {
+				err := c8.runFrame()
+				if err != nil {
+					return stopError, err
+				}
+			}
+
+			c8.update()
+			c8.recordFrame()
+
+			if c8.registerCSV != nil {
+				c8.writeRegisterRow()
+			}
+
+			c8.updateAudio()
+			continue
+		}
+
+		if c8.timerOrder == TimersBeforeCycle {
+			c8.tickTimersN(ticks)
+		}
+
+		// Run every cycle that has fallen due, catching up on time spent sleeping or drawing, up to a frame's worth
+		interval := c8.cycleInterval()
+		catchUp := max(interval, TIMER_INTERVAL)
+		due := 1
+		if interval > 0 {
+			due = int(min(c8.clock.Since(lastCycleTime), catchUp) / interval)
+			lastCycleTime = lastCycleTime.Add(time.Duration(due) * interval)
+
+			// Anything further behind than that is dropped rather than run in a burst
+			if c8.clock.Since(lastCycleTime) > catchUp {
+				lastCycleTime = c8.clock.Now()
+			}
+		}
+
+		for range due {
+			err := c8.cycle()
+			if err != nil {
+				return stopError, err
+			}
+
+			if c8.halted {
+				break
+			}
+		}
+
+		if due > 0 {
+			c8.update()
+			c8.recordFrame()
+
+			if c8.registerCSV != nil {
+				c8.writeRegisterRow()
+			}
+		}
+
+		if c8.timerOrder == TimersAfterCycle {
+			c8.tickTimersN(ticks)
+		}
+
+		c8.updateAudio()
+
+		// Nothing to do until the next cycle or timer tick falls due
+		if due == 0 {
+			c8.clock.Sleep(min(interval-c8.clock.Since(lastCycleTime), TIMER_INTERVAL-c8.clock.Since(lastTimerTick)))
+		}
+	}
+}
+
+/*
+Plays each ROM for perROM before moving on to the next, looping back to the first after the last, until the user quits.
+The machine is reset between ROMs and a ROM that halts with HaltExit set advances early.
+*/
+func (c8 *chip8) RunPlaylist(roms []string, perROM time.Duration) error {
+	if len(roms) == 0 {
+		return fmt.Errorf("playlist is empty")
+	}
+
+	for {
+		for _, rom := range roms {
+			c8.reset()
+
+			err := c8.LoadChip8ROM(rom)
+			if err != nil {
+				return err
+			}
+
+			reason, err := c8.runFor(perROM)
+			if err != nil {
+				return err
+			}
+
+			if reason == stopQuit {
+				return nil
+			}
+		}
+	}
+}
+
+/*
+Sets a wall-clock ceiling after which Run() returns even if the ROM never stops, zero meaning no limit.
+TimedOut() reports whether the last Run() ended this way.
+*/
+func (c8 *chip8) SetMaxRunTime(d time.Duration) {
+	c8.maxRunTime = d
+}
+
+// Reports whether the last Run() returned because the max run time was reached
+func (c8 *chip8) TimedOut() bool {
+	return c8.timedOut
+}
+
+// Reports whether the sound timer is currently running, which is when a ROM expects the buzzer to sound
+func (c8 *chip8) IsBeeping() bool {
+	return c8.soundTimer > 0
+}
+
+/*
+Returns a nearest-neighbour upscaled copy of the display, indexed [y][x], matching what the window shows at the
+given scale. Lets tooling capture frames at on-screen size without going through SDL.
+*/
+func (c8 *chip8) ScaledFramebuffer(scale int) [][]uint32 {
+	c8.stateLock.Lock()
+	defer c8.stateLock.Unlock()
+
+	scale = max(scale, 1)
+	width, height := c8.Resolution()
+
+	buffer := make([][]uint32, height*scale)
+	for y := range buffer {
+		buffer[y] = make([]uint32, width*scale)
+
+		for x := range buffer[y] {
+			buffer[y][x] = c8.pixelColor((y/scale)*width + x/scale)
+		}
+	}
+
+	return buffer
+}
+
+// Chooses whether Run() idles on the final frame or returns once the program halts
+func (c8 *chip8) SetOnHalt(behavior HaltBehavior) {
+	c8.onHalt = behavior
+}
 
-	buffer, err := io.ReadAll(file)
-	if err != nil {
-		return err
+// Stops Run() from executing instructions or ticking timers until Resume() is called; input and rendering carry on
+func (c8 *chip8) Pause() {
+	c8.paused = true
+	if c8.clearKeysOnPause {
+		c8.releaseKeys()
 	}
+	c8.showStatus("paused")
+	c8.emit(EventPaused)
+}
 
-	// Load the ROM contents into the Chip8's memory, starting at 0x200
-	for i, b := range buffer {
-		c8.memory[int(START_ADDRESS)+i] = b
+// Lets Run() carry on executing after Pause()
+func (c8 *chip8) Resume() {
+	c8.paused = false
+	if c8.clearKeysOnResume {
+		c8.releaseKeys()
 	}
+	c8.showStatus("resumed")
+	c8.emit(EventResumed)
+}
 
-	return nil
+/*
+Chooses whether held keys are released when execution is paused and/or when it resumes. Keys held down while paused
+would otherwise still read as pressed once the game carries on; a released key has to be pressed again to register.
+*/
+func (c8 *chip8) SetKeypadClearing(onPause, onResume bool) {
+	c8.clearKeysOnPause = onPause
+	c8.clearKeysOnResume = onResume
 }
 
-func (c8 *chip8) processInput() bool {
-	quit := false
+// Releases every key on the keypad, including any Fx0A is waiting to see let go
+func (c8 *chip8) releaseKeys() {
+	clear(c8.keypad[:])
+	clear(c8.keyWaitHeld[:])
+}
 
-	for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
-		switch t := event.(type) {
-		case *sdl.QuitEvent:
-			quit = true
-		case *sdl.KeyboardEvent:
-			var s byte = 0
-			if t.Type == sdl.KEYDOWN {
-				s = 1
-			}
+// Reports whether execution is paused
+func (c8 *chip8) Paused() bool {
+	return c8.paused
+}
 
-			switch t.Keysym.Sym {
-			case sdl.K_ESCAPE:
-				if s == 1 {
-					quit = true
-				}
-			case sdl.K_x:
-				c8.keypad[0] = s
-			case sdl.K_1:
-				c8.keypad[1] = s
-			case sdl.K_2:
-				c8.keypad[2] = s
-			case sdl.K_3:
-				c8.keypad[3] = s
-			case sdl.K_q:
-				c8.keypad[4] = s
-			case sdl.K_w:
-				c8.keypad[5] = s
-			case sdl.K_e:
-				c8.keypad[6] = s
-			case sdl.K_a:
-				c8.keypad[7] = s
-			case sdl.K_s:
-				c8.keypad[8] = s
-			case sdl.K_d:
-				c8.keypad[9] = s
-			case sdl.K_z:
-				c8.keypad[0xA] = s
-			case sdl.K_c:
-				c8.keypad[0xB] = s
-			case sdl.K_4:
-				c8.keypad[0xC] = s
-			case sdl.K_r:
-				c8.keypad[0xD] = s
-			case sdl.K_f:
-				c8.keypad[0xE] = s
-			case sdl.K_v:
-				c8.keypad[0xF] = s
-			}
-		}
+// Pauses a running emulator or resumes a paused one
+func (c8 *chip8) togglePause() {
+	if c8.paused {
+		c8.Resume()
+	} else {
+		c8.Pause()
 	}
+}
 
-	return quit
+// Reports whether the program has halted itself with a jump to its own address
+func (c8 *chip8) Halted() bool {
+	return c8.halted
 }
 
 /*
-When we talk about one cycle of this primitive CPU that we're emulating, we're talking about it doing three things:
-- Fetch the next instruction in the form of an opcode
-- Decode the instruction to determine what operation needs to occur
-- Execute the instruction
+Replaces the whole display with buf, indexed [y][x], for front ends that compute frames elsewhere.
+Each pixel is one of the plane colors, which decides the planes it's lit in; with the default colors 0xFFFFFFFF
+lights just the first plane, as on the classic display. The buffer must match the active resolution. The buffer is
+checked before anything is copied so a bad one leaves the display untouched. The swap happens under the state
+lock, between instructions, so it's safe to call from another goroutine while Run() is going.
 */
-func (c8 *chip8) cycle() {
-	// Fetch
-	c8.opcode = uint16(c8.memory[c8.programCounter])<<8 | uint16(c8.memory[c8.programCounter+1])
+func (c8 *chip8) SetDisplayBuffer(buf [][]uint32) error {
+	// The resolution and plane colors can change mid-cycle, so they're checked under the same lock as the write
+	c8.stateLock.Lock()
+	defer c8.stateLock.Unlock()
 
-	// Increment the PC before we execute anything
-	c8.programCounter += 2
+	width, height := c8.Resolution()
 
-	// Decode and Execute
-	switch c8.opcode & 0xF000 {
-	case 0x0000:
-		switch c8.opcode & 0x000F {
-		case 0x0000:
-			c8.op00E0()
-		case 0x000E:
-			c8.op00EE()
-		}
-	case 0x1000:
-		c8.op1nnn()
-	case 0x2000:
-		c8.op2nnn()
-	case 0x3000:
-		c8.op3xkk()
-	case 0x4000:
-		c8.op4xkk()
-	case 0x5000:
-		c8.op5xy0()
-	case 0x6000:
-		c8.op6xkk()
-	case 0x7000:
-		c8.op7xkk()
-	case 0x8000:
-		switch c8.opcode & 0x000F {
-		case 0x0000:
-			c8.op8xy0()
-		case 0x0001:
-			c8.op8xy1()
-		case 0x0002:
-			c8.op8xy2()
-		case 0x0003:
-			c8.op8xy3()
-		case 0x0004:
-			c8.op8xy4()
-		case 0x0005:
-			c8.op8xy5()
-		case 0x0006:
-			c8.op8xy6()
-		case 0x0007:
-			c8.op8xy7()
-		case 0x000E:
-			c8.op8xyE()
+	if len(buf) != height {
+		return fmt.Errorf("display buffer has %d rows, expected %d", len(buf), height)
+	}
+
+	combinations := make([]int, 0, width*height)
+	for y, row := range buf {
+		if len(row) != width {
+			return fmt.Errorf("display buffer row %d has %d columns, expected %d", y, len(row), width)
 		}
-	case 0x9000:
-		c8.op9xy0()
-	case 0xA000:
-		c8.opAnnn()
-	case 0xB000:
-		c8.opBnnn()
-	case 0xC000:
-		c8.opCxkk()
-	case 0xD000:
-		c8.opDxyn()
-	case 0xE000:
-		switch c8.opcode & 0x000F {
-		case 0x0001:
-			c8.opExA1()
-		case 0x000E:
-			c8.opEx9E()
+
+		for x, color := range row {
+			combination := slices.Index(c8.planeColors[:], color)
+			if combination < 0 {
+				return fmt.Errorf("display buffer pixel (%d, %d) is 0x%08X, which isn't a plane color", x, y, color)
+			}
+			combinations = append(combinations, combination)
 		}
-	case 0xF000:
-		switch c8.opcode & 0x00FF {
-		case 0x0007:
-			c8.opFx07()
-		case 0x000A:
-			c8.opFx0A()
-		case 0x0015:
-			c8.opFx15()
-		case 0x0018:
-			c8.opFx18()
-		case 0x001E:
-			c8.opFx1E()
-		case 0x0029:
-			c8.opFx29()
-		case 0x0033:
-			c8.opFx33()
-		case 0x0055:
-			c8.opFx55()
-		case 0x0065:
-			c8.opFx65()
+	}
+
+	for p := range PLANE_COUNT {
+		plane := c8.plane(p)
+
+		for k, combination := range combinations {
+			plane[k] = 0x00000000
+			if combination&(1<<p) != 0 {
+				plane[k] = 0xFFFFFFFF
+			}
 		}
-	default:
-		log.Fatal("cannot interpret instruction:", c8.opcode)
 	}
 
-	// Decrement the delay timer if it's been set
-	if c8.delayTimer > 0 {
-		c8.delayTimer -= 1
+	return nil
+}
+
+// Returns the color the display pixel at (x, y) is shown in, from the planes it's lit in, or 0 if it's off screen
+func (c8 *chip8) GetPixel(x int, y int) uint32 {
+	c8.stateLock.Lock()
+	defer c8.stateLock.Unlock()
+
+	width, height := c8.Resolution()
+
+	if x < 0 || x >= width || y < 0 || y >= height {
+		return 0
 	}
 
-	// Decrement the sound timer if it's been set
-	if c8.soundTimer > 0 {
-		c8.soundTimer -= 1 // TODO: Implement sound
+	return c8.pixelColor(y*width + x)
+}
+
+// Returns the width and height of the active display: 64x32, or 128x64 in SUPER-CHIP high-res mode
+func (c8 *chip8) Resolution() (int, int) {
+	if c8.hires {
+		return HIRES_VIDEO_WIDTH, HIRES_VIDEO_HEIGHT
 	}
+
+	return VIDEO_WIDTH, VIDEO_HEIGHT
 }
 
-// Update the display
-func (c8 *chip8) update() {
-	// Clear surface
-	c8.surface.FillRect(nil, 0)
+/*
+Moves every pixel of the selected planes by (dx, dy). Pixels pushed off an edge are lost rather than wrapped,
+and the rows or columns left behind are cleared.
+*/
+func (c8 *chip8) scrollDisplay(dx int, dy int) {
+	width, height := c8.Resolution()
 
-	// Draw on the surface
-	row := 0
-	for k, color := range c8.pixels {
-		col := k % VIDEO_WIDTH
-		if col == 0 && k != 0 {
-			row++
-		}
+	for _, plane := range c8.selectedPlanes() {
+		var scrolled displayPlane
 
-		yPos := int32(row * c8.videoScale)
-		xPos := int32(col * c8.videoScale)
-		pixel := &sdl.Rect{X: xPos, Y: yPos, W: int32(c8.videoScale), H: int32(c8.videoScale)}
+		for y := range height {
+			for x := range width {
+				fromX := x - dx
+				fromY := y - dy
+
+				if fromX >= 0 && fromX < width && fromY >= 0 && fromY < height {
+					scrolled[y*width+x] = plane[fromY*width+fromX]
+				}
+			}
+		}
 
-		c8.surface.FillRect(pixel, color)
+		*plane = scrolled
 	}
+}
 
-	c8.window.UpdateSurface()
+// Switches between the low and high-res displays. The pixel layout changes with the width, so the display is cleared
+func (c8 *chip8) setHires(enabled bool) {
+	c8.hires = enabled
+	c8.lastDraw = nil
+	clear(c8.persistFrames[:])
+	clear(c8.ghostBrightness[:])
+	c8.clearAllPlanes()
 }
 
 /*
-Our main loop that will call our cycle() receiver method continuously until exit, handle input, and render with SDL.
-
-With each iteration of the loop: input from the keyboard is parsed, a delay is checked to see if enough time has
-passed between cycles and a cycle is run if so, and the screen is updated.
+Populates the selected planes from a function of each pixel's coordinates, on when fn returns true; the other
+planes are left alone. Useful for setting up stripes or gradients to exercise drawing and scrolling.
 */
-func (c8 *chip8) Run() {
-	lastCycleTime := time.Now()
-	quit := false
+func (c8 *chip8) FillDisplayPattern(fn func(x, y int) bool) {
+	c8.stateLock.Lock()
+	defer c8.stateLock.Unlock()
 
-	for !quit {
-		quit = c8.processInput()
+	width, height := c8.Resolution()
 
-		d := float64(time.Since(lastCycleTime).Milliseconds())
+	for _, plane := range c8.selectedPlanes() {
+		for k := range plane[:width*height] {
+			plane[k] = 0x00000000
 
-		if d > c8.cycleDelay {
-			lastCycleTime = time.Now()
-			c8.cycle()
-			c8.update()
+			if fn(k%width, k/width) {
+				plane[k] = 0xFFFFFFFF
+			}
 		}
 	}
 }
@@ -409,11 +1949,11 @@ https://github.com/mattmikolay/chip-8/wiki/CHIP%E2%80%908-Instruction-Set
 
 /*
 00E0: CLS
-Clear the display
+Clear the display; with XO-CHIP planes, only the selected planes are cleared
 */
 func (c8 *chip8) op00E0() {
-	for k := range c8.pixels {
-		c8.pixels[k] = 0x00000000
+	for _, plane := range c8.selectedPlanes() {
+		clear(plane[:])
 	}
 }
 
@@ -422,8 +1962,55 @@ func (c8 *chip8) op00E0() {
 Return from a subroutine
 */
 func (c8 *chip8) op00EE() {
+	if c8.stackPointer == 0 {
+		c8.fail(fmt.Errorf("%w: return with an empty stack", ErrStackUnderflow))
+		return
+	}
+
 	c8.stackPointer -= 1
 	c8.programCounter = c8.stack[c8.stackPointer]
+
+	c8.traceReturn()
+}
+
+/*
+00Cn: SCD nibble
+Scroll the display down n pixels (SUPER-CHIP)
+*/
+func (c8 *chip8) op00Cn() {
+	c8.scrollDisplay(0, int(c8.opcode&0x000F))
+}
+
+/*
+00FB: SCR
+Scroll the display right 4 pixels (SUPER-CHIP)
+*/
+func (c8 *chip8) op00FB() {
+	c8.scrollDisplay(4, 0)
+}
+
+/*
+00FC: SCL
+Scroll the display left 4 pixels (SUPER-CHIP)
+*/
+func (c8 *chip8) op00FC() {
+	c8.scrollDisplay(-4, 0)
+}
+
+/*
+00FE: LOW
+Switch to the 64x32 low-res display (SUPER-CHIP)
+*/
+func (c8 *chip8) op00FE() {
+	c8.setHires(false)
+}
+
+/*
+00FF: HIGH
+Switch to the 128x64 high-res display (SUPER-CHIP)
+*/
+func (c8 *chip8) op00FF() {
+	c8.setHires(true)
 }
 
 /*
@@ -431,10 +2018,18 @@ func (c8 *chip8) op00EE() {
 Jump to location nnn.
 The interpreter sets the program counter to nnn.
 A jump doesn't remember its origin, so no stack interaction required.
+A jump to its own address is treated as the program halting.
 */
 func (c8 *chip8) op1nnn() {
 	// Use bitwise AND to find our jump location in our memory array
 	address := c8.opcode & 0x0FFF
+
+	// A jump to itself is how ROMs stop; nothing will ever happen again
+	if address == c8.programCounter-2 && !c8.halted {
+		c8.halted = true
+		c8.emit(EventHalted)
+	}
+
 	c8.programCounter = address
 }
 
@@ -444,9 +2039,17 @@ Call subroutine at nnn.
 */
 func (c8 *chip8) op2nnn() {
 	address := c8.opcode & 0x0FFF
+
+	if int(c8.stackPointer) >= len(c8.stack) {
+		c8.fail(fmt.Errorf("%w: call nested deeper than %d levels", ErrStackOverflow, len(c8.stack)))
+		return
+	}
+
 	c8.stack[c8.stackPointer] = c8.programCounter
 	c8.stackPointer += 1
 	c8.programCounter = address
+
+	c8.traceCall(address)
 }
 
 /*
@@ -459,7 +2062,7 @@ func (c8 *chip8) op3xkk() {
 	b := byte(c8.opcode & 0x00FF)
 
 	if c8.registers[vx] == b {
-		c8.programCounter += 2
+		c8.skipInstruction()
 	}
 }
 
@@ -473,7 +2076,7 @@ func (c8 *chip8) op4xkk() {
 	b := byte(c8.opcode & 0x00FF)
 
 	if c8.registers[vx] != b {
-		c8.programCounter += 2
+		c8.skipInstruction()
 	}
 }
 
@@ -487,7 +2090,7 @@ func (c8 *chip8) op5xy0() {
 	vy := byte((c8.opcode & 0x00F0) >> 4)
 
 	if c8.registers[vx] == c8.registers[vy] {
-		c8.programCounter += 2
+		c8.skipInstruction()
 	}
 }
 
@@ -533,6 +2136,8 @@ func (c8 *chip8) op8xy1() {
 	vy := byte((c8.opcode & 0x00F0) >> 4)
 
 	c8.registers[vx] |= c8.registers[vy]
+
+	c8.poisonVF(vx)
 }
 
 /*
@@ -544,6 +2149,8 @@ func (c8 *chip8) op8xy2() {
 	vy := byte((c8.opcode & 0x00F0) >> 4)
 
 	c8.registers[vx] &= c8.registers[vy]
+
+	c8.poisonVF(vx)
 }
 
 /*
@@ -555,6 +2162,8 @@ func (c8 *chip8) op8xy3() {
 	vy := byte((c8.opcode & 0x00F0) >> 4)
 
 	c8.registers[vx] ^= c8.registers[vy]
+
+	c8.poisonVF(vx)
 }
 
 /*
@@ -596,19 +2205,27 @@ func (c8 *chip8) op8xy5() {
 }
 
 /*
-8xy6 - SHR Vx
-Set Vx = Vx SHR 1.
+8xy6 - SHR Vx {, Vy}
+Set Vx = Vx SHR 1, or Vx = Vy SHR 1 with the shift quirk enabled.
 If the least-significant bit of Vx is 1, then VF is set to 1, otherwise 0. Then Vx is divided by 2.
 A right shift is performed (division by 2), and the least significant bit is saved in Register VF.
+VF is written last so that when x is F the flag wins over the shifted value.
 */
 func (c8 *chip8) op8xy6() {
 	vx := byte((c8.opcode & 0x0F00) >> 8)
+	vy := byte((c8.opcode & 0x00F0) >> 4)
+
+	if c8.shiftUsesVy {
+		c8.registers[vx] = c8.registers[vy]
+	}
 
-	// Save the least significant bit in register VF
-	c8.registers[0xF] = c8.registers[vx] & 0x1
+	// Save the least significant bit before the shift discards it
+	carry := c8.registers[vx] & 0x1
 
 	// Division by two using bitwise shift
 	c8.registers[vx] >>= 1
+
+	c8.registers[0xF] = carry
 }
 
 /*
@@ -631,17 +2248,25 @@ func (c8 *chip8) op8xy7() {
 
 /*
 8xyE - SHL Vx {, Vy}
-Set Vx = Vx SHL 1.
+Set Vx = Vx SHL 1, or Vx = Vy SHL 1 with the shift quirk enabled.
 If the most-significant bit of Vx is 1, then VF is set to 1, otherwise to 0. Then Vx is multiplied by 2.
 A left shift is performed (multiplication by 2), and the most significant bit is saved in Register VF.
+VF is written last so that when x is F the flag wins over the shifted value.
 */
 func (c8 *chip8) op8xyE() {
 	vx := byte((c8.opcode & 0x0F00) >> 8)
+	vy := byte((c8.opcode & 0x00F0) >> 4)
+
+	if c8.shiftUsesVy {
+		c8.registers[vx] = c8.registers[vy]
+	}
 
-	// Save the most significant bit in register VF
-	c8.registers[0xF] = (c8.registers[vx] & 0x80) >> 7
+	// Save the most significant bit before the shift discards it
+	carry := (c8.registers[vx] & 0x80) >> 7
 
 	c8.registers[vx] <<= 1
+
+	c8.registers[0xF] = carry
 }
 
 /*
@@ -654,7 +2279,7 @@ func (c8 *chip8) op9xy0() {
 	vy := byte((c8.opcode & 0x00F0) >> 4)
 
 	if c8.registers[vx] != c8.registers[vy] {
-		c8.programCounter += 2
+		c8.skipInstruction()
 	}
 }
 
@@ -669,11 +2294,26 @@ func (c8 *chip8) opAnnn() {
 
 /*
 Bnnn - JP V0, addr
-Jump to location nnn + V0.
+Jump to location nnn + V0, or xnn + Vx with the Bxnn jump quirk enabled.
+nnn + V0 can land past the end of memory (0xFFF + 0xFF), so the target is masked to the address space, or fails with strict jumps.
+A target on the very last byte still has no whole instruction to fetch, which the next cycle reports.
 */
 func (c8 *chip8) opBnnn() {
 	address := c8.opcode & 0x0FFF
-	c8.programCounter = uint16(c8.registers[0]) + address
+	offset := c8.registers[0]
+
+	if c8.jumpUsesVx {
+		offset = c8.registers[(c8.opcode&0x0F00)>>8]
+	}
+
+	target := uint16(offset) + address
+
+	if c8.strictJumps && int(target) >= len(c8.memory) {
+		c8.fail(fmt.Errorf("%w: jump target 0x%04X", ErrOutOfBounds, target))
+		return
+	}
+
+	c8.programCounter = target & uint16(len(c8.memory)-1)
 }
 
 /*
@@ -684,43 +2324,89 @@ func (c8 *chip8) opCxkk() {
 	vx := byte((c8.opcode & 0x0F00) >> 8)
 	b := byte(c8.opcode & 0x00FF)
 
-	c8.registers[vx] += randomByte() & b
+	c8.registers[vx] = c8.randomByte() & b
 }
 
 /*
 Dxyn - DRW Vx, Vy, nibble
 Display n-byte sprite starting at memory location I at (Vx, Vy), set VF = collision.
-We iterate over the sprite, row by row and column by column. We know there are eight columns because a sprite is guaranteed to be eight pixels wide.
+We iterate over the sprite, row by row and column by column. Sprites are eight pixels wide, one byte per row.
+When n is 0 in high-res mode the SUPER-CHIP Dxy0 form is drawn instead: a 16x16 sprite of two bytes per row, 32 bytes in all. In low-res mode Dxy0 draws nothing.
+Each sprite pixel lands at (Vx+col, Vy+row), wrapped to the screen, so sprites crossing an edge reappear on the opposite side.
 If a sprite pixel is on then there may be a collision with what's already being displayed, so we check if our screen pixel in the same location is set. If so we must set the VF register to express collision.
 Then we can just XOR the screen pixel with 0xFFFFFFFF to essentially XOR it with the sprite pixel (which we now know is on). We can't XOR directly because the sprite pixel is either 1 or 0 while our video pixel is either 0x00000000 or 0xFFFFFFFF.
+In DrawOR mode sprite pixels are only ever turned on, so nothing is erased; the mode is set per plane and only XOR planes set VF.
 */
 func (c8 *chip8) opDxyn() {
+	// Out of draws for this frame: come back to this instruction until the next one
+	if c8.drawsPerFrame > 0 && c8.frameDraws >= c8.drawsPerFrame {
+		c8.programCounter -= 2
+		return
+	}
+	c8.frameDraws++
+
 	vx := byte((c8.opcode & 0x0F00) >> 8)
 	vy := byte((c8.opcode & 0x00F0) >> 4)
 	height := uint16(c8.opcode & 0x000F)
 
 	// Wrap if going beyond screen boundaries
-	xPos := c8.registers[vx] % VIDEO_WIDTH
-	yPos := c8.registers[vy] % VIDEO_HEIGHT
+	screenWidth, screenHeight := c8.Resolution()
+	xPos := uint16(c8.registers[vx]) % uint16(screenWidth)
+	yPos := uint16(c8.registers[vy]) % uint16(screenHeight)
+
+	spriteWidth := uint16(8)
+	if height == 0 && c8.hires {
+		spriteWidth = 16
+		height = 16
+	}
+	bytesPerRow := spriteWidth / 8
 
 	c8.registers[0xF] = 0
+	c8.lastDraw = &sdl.Rect{X: int32(xPos), Y: int32(yPos), W: int32(spriteWidth), H: int32(height)}
 
-	for row := uint16(0); row < height; row++ {
-		spriteByte := c8.memory[c8.indexRegister+row]
+	// Each selected plane gets its own copy of the sprite, one after the other in memory
+	planes := c8.selectedPlaneIndices()
+	spriteSize := height * bytesPerRow
+	if !c8.checkIndexRange(spriteSize * uint16(len(planes))) {
+		return
+	}
+	spriteData := c8.spriteRows(spriteSize * uint16(len(planes)))
 
-		for col := byte(0); col < 8; col++ {
-			spritePixel := spriteByte & (0x80 >> col)
-			screenPixelIndex := (uint16(yPos)+uint16(row))*VIDEO_WIDTH + (uint16(xPos) + uint16(col))
+	for i, p := range planes {
+		plane := c8.plane(p)
+		planeData := spriteData[uint16(i)*spriteSize:]
 
-			// Sprite pixel is on
-			if spritePixel != 0 {
-				// Screen pixel also on - collision
-				if c8.pixels[screenPixelIndex] == 0xFFFFFFFF {
-					c8.registers[0xF] = 1
-				}
+		for row := range height {
+			// Line the row up against the top bit so 8 and 16 pixel wide sprites are read the same way
+			var spriteRow uint16
+			for b := range bytesPerRow {
+				spriteRow |= uint16(planeData[row*bytesPerRow+b]) << (8 * (1 - b))
+			}
 
-				// Effectively XOR with the sprite pixel
-				c8.pixels[screenPixelIndex] ^= 0xFFFFFFFF
+			for col := uint16(0); col < spriteWidth; col++ {
+				spritePixel := spriteRow & (0x8000 >> col)
+
+				// Parts of the sprite that run off the edge wrap around to the other side
+				x := (xPos + col) % uint16(screenWidth)
+				y := (yPos + row) % uint16(screenHeight)
+				screenPixelIndex := y*uint16(screenWidth) + x
+
+				// Sprite pixel is on
+				if spritePixel != 0 {
+					// OR drawing never erases, so there's nothing to collide with
+					if c8.drawModes[p] == DrawOR {
+						plane[screenPixelIndex] = 0xFFFFFFFF
+						continue
+					}
+
+					// Screen pixel also on - collision
+					if plane[screenPixelIndex] != 0 {
+						c8.registers[0xF] = 1
+					}
+
+					// Effectively XOR with the sprite pixel
+					plane[screenPixelIndex] ^= 0xFFFFFFFF
+				}
 			}
 		}
 	}
@@ -729,29 +2415,57 @@ func (c8 *chip8) opDxyn() {
 /*
 Ex9E - SKP Vx
 Skip next instruction if key with the value of Vx is pressed.
+Only the low nibble of Vx is used since there are just 16 keys.
 Since our PC has already been incremented by 2 in cycle(), we can just increment by 2 again to skip the next instruction.
 */
 func (c8 *chip8) opEx9E() {
 	vx := byte((c8.opcode & 0x0F00) >> 8)
-	key := c8.registers[vx]
+	key := c8.registers[vx] & 0xF
 
 	if c8.keypad[key] != 0 {
-		c8.programCounter += 2
+		c8.skipInstruction()
 	}
 }
 
 /*
 ExA1 - SKNP Vx
 Skip next instruction if key with the value of Vx is not pressed.
+Only the low nibble of Vx is used since there are just 16 keys.
 Since our PC has already been incremented by 2 in cycle(), we can just increment by 2 again to skip the next instruction.
 */
 func (c8 *chip8) opExA1() {
 	vx := byte((c8.opcode & 0x0F00) >> 8)
-	key := c8.registers[vx]
+	key := c8.registers[vx] & 0xF
 
 	if c8.keypad[key] == 0 {
-		c8.programCounter += 2
+		c8.skipInstruction()
+	}
+}
+
+/*
+F000 NNNN - LD I, long addr
+Set I = NNNN, read from the two bytes following the instruction (XO-CHIP).
+The program counter is advanced past them so they're never executed. Only decoded with extended memory enabled.
+*/
+func (c8 *chip8) opF000() {
+	// The address follows the instruction, which can't be read if the instruction is the last word in memory
+	address := int(c8.programCounter - 2)
+	if address+3 >= len(c8.memory) {
+		c8.fail(fmt.Errorf("%w: long load address at 0x%04X", ErrOutOfBounds, address+2))
+		return
 	}
+
+	c8.indexRegister = c8.peekOpcode()
+	c8.programCounter += 2
+}
+
+/*
+Fn01 - PLANE n
+Select the bitplanes that drawing, clearing and scrolling act on, as a bitmask (XO-CHIP).
+0 selects no planes, 1 the classic display, 2 the second plane and 3 both.
+*/
+func (c8 *chip8) opFn01() {
+	c8.planeMask = byte((c8.opcode&0x0F00)>>8) & (1<<PLANE_COUNT - 1)
 }
 
 /*
@@ -768,13 +2482,21 @@ Fx0A - LD Vx, K
 Wait for a key press, store the value of the key in Vx.
 The easiest way to "wait" is to decrement the PC by 2 whenever a keypad value is not detected.
 This has the effect of running the same instruction repeatedly.
+Like the original interpreter, the key only counts once it's released, so a held key isn't read again by the next Fx0A.
+The timers keep counting down while waiting, since Run() ticks them no matter which instruction is running.
 */
 func (c8 *chip8) opFx0A() {
 	vx := byte((c8.opcode & 0x0F00) >> 8)
 
 	for k, v := range c8.keypad {
 		if v != 0 {
+			c8.keyWaitHeld[k] = true
+			continue
+		}
+
+		if c8.keyWaitHeld[k] {
 			c8.registers[vx] = byte(k)
+			clear(c8.keyWaitHeld[:])
 			return
 		}
 	}
@@ -821,6 +2543,19 @@ func (c8 *chip8) opFx29() {
 	c8.indexRegister = uint16(FONTSET_START_ADDRESS) + (5 * digit)
 }
 
+/*
+Fx30 - LD HF, Vx
+Set I = location of the big 8x10 sprite for digit Vx (SUPER-CHIP).
+Works like Fx29 but the big font starts at 0xA0 and each character is ten bytes. Only the low nibble of Vx is used so I
+always lands on a character.
+*/
+func (c8 *chip8) opFx30() {
+	vx := byte((c8.opcode & 0x0F00) >> 8)
+	digit := uint16(c8.registers[vx] & 0xF)
+
+	c8.indexRegister = uint16(BIG_FONTSET_START_ADDRESS) + (10 * digit)
+}
+
 /*
 Fx33 - LD B, Vx
 Store BCD representation of Vx in memory locations I, I+1, and I+2.
@@ -830,6 +2565,10 @@ func (c8 *chip8) opFx33() {
 	vx := byte((c8.opcode & 0x0F00) >> 8)
 	value := c8.registers[vx]
 
+	if !c8.checkIndexRange(3) {
+		return
+	}
+
 	// hundreds := value / 100
 	// c8.memory[c8.indexRegister] = hundreds
 
@@ -839,35 +2578,76 @@ func (c8 *chip8) opFx33() {
 	// ones := value - (hundreds*100 + tens*10)
 	// c8.memory[c8.indexRegister+2] = ones
 
-	c8.memory[c8.indexRegister] = value / 100
-	c8.memory[c8.indexRegister+1] = (value / 10) % 10
-	c8.memory[c8.indexRegister+2] = (value % 100) / 10
+	c8.writeMemory(c8.indexRegister, value/100)
+	c8.writeMemory(c8.indexRegister+1, (value/10)%10)
+	c8.writeMemory(c8.indexRegister+2, value%10)
 }
 
 /*
 Fx55 - LD [I], Vx
 Store registers V0 through Vx in memory starting at location I.
+I is then advanced to the byte after the last register stored, unless the index increment quirk is turned off.
 */
 func (c8 *chip8) opFx55() {
 	vx := byte((c8.opcode & 0x0F00) >> 8)
+	if !c8.checkIndexRange(uint16(vx) + 1) {
+		return
+	}
 
-	for i := byte(0); i <= vx; i++ {
-		c8.memory[byte(c8.indexRegister)+i] = c8.registers[i]
+	for i := uint16(0); i <= uint16(vx); i++ {
+		c8.writeMemory(c8.indexRegister+i, c8.registers[i])
+	}
+
+	if c8.incrementIndex {
+		c8.indexRegister += uint16(vx) + 1
 	}
 }
 
 /*
 Fx65 - LD Vx, [I]
 Read registers V0 through Vx from memory starting at location I.
+I is then advanced to the byte after the last register loaded, unless the index increment quirk is turned off.
 */
 func (c8 *chip8) opFx65() {
 	vx := byte((c8.opcode & 0x0F00) >> 8)
+	if !c8.checkIndexRange(uint16(vx) + 1) {
+		return
+	}
+
+	for i := uint16(0); i <= uint16(vx); i++ {
+		c8.registers[i] = c8.memory[c8.indexRegister+i]
+	}
+
+	if c8.incrementIndex {
+		c8.indexRegister += uint16(vx) + 1
+	}
+}
 
-	for i := byte(0); i <= vx; i++ {
-		c8.registers[i] = c8.memory[byte(c8.indexRegister)+i]
+// Reports whether n bytes starting at I fit in memory, failing the instruction if they don't
+func (c8 *chip8) checkIndexRange(n uint16) bool {
+	if int(c8.indexRegister)+int(n) > len(c8.memory) {
+		c8.fail(fmt.Errorf("%w: %d bytes at I = 0x%04X run past the end of memory", ErrOutOfBounds, n, c8.indexRegister))
+		return false
+	}
+
+	return true
+}
+
+/*
+Records an error that stops the instruction being executed, so it can be returned to whoever is running the machine
+instead of taking the whole process down. Only the first error of an instruction is kept.
+*/
+func (c8 *chip8) fail(err error) {
+	if c8.fault == nil {
+		c8.fault = err
 	}
 }
 
-func randomByte() byte {
-	return byte(rand.IntN(255))
+// Returns a uniformly random byte in 0x00-0xFF, from the seeded source if one was set
+func (c8 *chip8) randomByte() byte {
+	if c8.rng != nil {
+		return byte(c8.rng.IntN(256))
+	}
+
+	return byte(rand.IntN(256))
 }
@@ -0,0 +1,1902 @@
+package emulator
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// Builds a machine without a window and loads rom at START_ADDRESS
+func newTestCore(t testing.TB, rom ...byte) *chip8 {
+	t.Helper()
+
+	c8 := newCore(10, 0)
+
+	err := c8.loadROM(rom)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return c8
+}
+
+// Executes n instructions, failing the test on the first error
+func stepN(t testing.TB, c8 *chip8, n int) {
+	t.Helper()
+
+	for range n {
+		_, err := c8.Step()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestUnknownOpcodeError(t *testing.T) {
+	tests := []struct {
+		name   string
+		rom    []byte
+		opcode string
+	}{
+		{"first instruction", []byte{0xFF, 0xFF}, "0xFFFF at 0x0200"},
+		{"after a jump", []byte{0x12, 0x04, 0x00, 0x00, 0x80, 0x0F}, "0x800F at 0x0204"},
+		{"E group", []byte{0xE1, 0x00}, "0xE100 at 0x0200"},
+		{"F group", []byte{0xF1, 0x99}, "0xF199 at 0x0200"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c8 := newTestCore(t, tt.rom...)
+
+			var err error
+			for range 2 {
+				_, err = c8.Step()
+				if err != nil {
+					break
+				}
+			}
+
+			if !errors.Is(err, ErrUnknownOpcode) {
+				t.Fatalf("got %v, want ErrUnknownOpcode", err)
+			}
+
+			if !strings.Contains(err.Error(), tt.opcode) {
+				t.Errorf("error %q does not identify %s", err, tt.opcode)
+			}
+		})
+	}
+}
+
+func TestOutOfBoundsErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		index uint16
+		rom   []byte
+	}{
+		// Fx55 storing V0-V3 from 0xFFE
+		{"store past the end", 0xFFE, []byte{0xF3, 0x55}},
+		// Fx65 loading V0-V1 from 0xFFF
+		{"load past the end", 0xFFF, []byte{0xF1, 0x65}},
+		// Fx33 writing three digits from 0xFFE
+		{"BCD past the end", 0xFFE, []byte{0xF0, 0x33}},
+		// Dxy5 reading five rows from 0xFFD
+		{"sprite past the end", 0xFFD, []byte{0xD0, 0x15}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c8 := newTestCore(t, tt.rom...)
+			c8.indexRegister = tt.index
+
+			crashed := false
+			c8.Subscribe(func(e Event) {
+				if e.Type == EventCrash {
+					crashed = true
+				}
+			})
+
+			_, err := c8.Step()
+			if !errors.Is(err, ErrOutOfBounds) {
+				t.Fatalf("got %v, want ErrOutOfBounds", err)
+			}
+
+			if !strings.Contains(err.Error(), "at 0x0200") {
+				t.Errorf("error %q does not give the instruction's address", err)
+			}
+
+			if !crashed {
+				t.Error("EventCrash was not emitted")
+			}
+
+			if len(c8.LastStepWrites()) != 0 {
+				t.Errorf("failed instruction wrote to %v", c8.LastStepWrites())
+			}
+		})
+	}
+}
+
+func TestOutOfBoundsErrorIsCleared(t *testing.T) {
+	// Fx55 at 0xFFF fails, then the same store from a valid I succeeds
+	c8 := newTestCore(t, 0xF0, 0x55, 0xF0, 0x55)
+	c8.indexRegister = 0x1000
+
+	_, err := c8.Step()
+	if !errors.Is(err, ErrOutOfBounds) {
+		t.Fatalf("got %v, want ErrOutOfBounds", err)
+	}
+
+	c8.indexRegister = 0x300
+
+	_, err = c8.Step()
+	if err != nil {
+		t.Fatalf("valid store failed with %v", err)
+	}
+}
+
+func TestStackBounds(t *testing.T) {
+	tests := []struct {
+		name  string
+		depth int
+		rom   []byte
+		steps int
+		want  error
+	}{
+		// 2200 calls itself forever, so the stack fills one entry per step
+		{"overflow at default depth", DEFAULT_STACK_DEPTH, []byte{0x22, 0x00}, DEFAULT_STACK_DEPTH + 1, ErrStackOverflow},
+		{"overflow at depth 1", 1, []byte{0x22, 0x00}, 2, ErrStackOverflow},
+		{"overflow at max depth", MAX_STACK_DEPTH, []byte{0x22, 0x00}, MAX_STACK_DEPTH + 1, ErrStackOverflow},
+		{"underflow", DEFAULT_STACK_DEPTH, []byte{0x00, 0xEE}, 1, ErrStackUnderflow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c8 := newTestCore(t, tt.rom...)
+
+			err := c8.SetStackDepth(tt.depth)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			stepN(t, c8, tt.steps-1)
+
+			sp := c8.stackPointer
+			_, err = c8.Step()
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+
+			if c8.stackPointer != sp {
+				t.Errorf("stack pointer moved from %d to %d", sp, c8.stackPointer)
+			}
+		})
+	}
+}
+
+func TestSetStackDepth(t *testing.T) {
+	tests := []struct {
+		depth int
+		ok    bool
+	}{
+		{0, false},
+		{1, true},
+		{DEFAULT_STACK_DEPTH, true},
+		{MAX_STACK_DEPTH, true},
+		{MAX_STACK_DEPTH + 1, false},
+	}
+
+	for _, tt := range tests {
+		c8 := newTestCore(t)
+
+		err := c8.SetStackDepth(tt.depth)
+		if (err == nil) != tt.ok {
+			t.Errorf("SetStackDepth(%d) = %v, want ok %v", tt.depth, err, tt.ok)
+		}
+	}
+
+	// Can't shrink below what's already on the stack
+	c8 := newTestCore(t, 0x22, 0x00)
+	stepN(t, c8, 4)
+
+	if c8.SetStackDepth(3) == nil {
+		t.Error("stack shrunk below 4 entries in use")
+	}
+}
+
+func TestBnnnJumpTarget(t *testing.T) {
+	tests := []struct {
+		name   string
+		v0     byte
+		opcode []byte
+		strict bool
+		wantPC uint16
+		err    error
+	}{
+		{"in range", 0x10, []byte{0xB3, 0x00}, false, 0x310, nil},
+		{"masked", 0xFF, []byte{0xBF, 0xFF}, false, 0x0FE, nil},
+		{"strict in range", 0x10, []byte{0xB3, 0x00}, true, 0x310, nil},
+		{"strict escape", 0xFF, []byte{0xBF, 0xFF}, true, 0x202, ErrOutOfBounds},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c8 := newTestCore(t, tt.opcode...)
+			c8.registers[0] = tt.v0
+			c8.SetStrictJumps(tt.strict)
+
+			_, err := c8.Step()
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got %v, want %v", err, tt.err)
+			}
+
+			if c8.programCounter != tt.wantPC {
+				t.Errorf("PC = 0x%04X, want 0x%04X", c8.programCounter, tt.wantPC)
+			}
+		})
+	}
+}
+
+func TestProgramCounterOutOfMemory(t *testing.T) {
+	tests := []struct {
+		name string
+		rom  []byte
+		v0   byte
+	}{
+		{"jump to the last byte", []byte{0x1F, 0xFF}, 0},
+		{"call to the last byte", []byte{0x2F, 0xFF}, 0},
+		{"Bnnn to the last byte", []byte{0xBF, 0xF0}, 0x0F},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c8 := newTestCore(t, tt.rom...)
+			c8.registers[0] = tt.v0
+			stepN(t, c8, 1)
+
+			_, err := c8.Step()
+			if !errors.Is(err, ErrOutOfBounds) {
+				t.Fatalf("got %v, want ErrOutOfBounds", err)
+			}
+		})
+	}
+
+	t.Run("running off the end", func(t *testing.T) {
+		c8 := newTestCore(t)
+		c8.LoadROMAt([]byte{0x00, 0xE0}, 0xFFE)
+		c8.SetProgramCounter(0xFFE)
+		stepN(t, c8, 1)
+
+		err := c8.headlessCycle()
+		if !errors.Is(err, ErrOutOfBounds) {
+			t.Fatalf("got %v, want ErrOutOfBounds", err)
+		}
+	})
+}
+
+func TestOnHalt(t *testing.T) {
+	tests := []struct {
+		name     string
+		behavior HaltBehavior
+		timedOut bool
+	}{
+		{"idle", HaltIdle, true},
+		{"exit", HaltExit, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 6F0A FF18 1204: start a 10 tick beep, then jump to itself
+			c8, clk := newClockedCore(t, 0x6F, 0x0A, 0xFF, 0x18, 0x12, 0x04)
+			renderer := &captureRenderer{}
+			c8.SetRenderer(renderer)
+			c8.SetCyclesPerFrame(3)
+			c8.SetOnHalt(tt.behavior)
+			c8.SetMaxRunTime(framesLimit(30))
+			start := clk.Now()
+
+			err := c8.Run()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !c8.Halted() {
+				t.Fatal("program didn't halt")
+			}
+
+			if c8.TimedOut() != tt.timedOut {
+				t.Errorf("TimedOut() = %v, want %v", c8.TimedOut(), tt.timedOut)
+			}
+
+			if tt.behavior == HaltExit {
+				if clk.Since(start) > 2*TIMER_INTERVAL {
+					t.Errorf("Run() took %v of simulated time to return after halting", clk.Since(start))
+				}
+				return
+			}
+
+			// The sound timer ran out while idling and every tick was drawn, without spinning in between
+			if c8.soundTimer != 0 {
+				t.Errorf("sound timer stuck at %d while halted", c8.soundTimer)
+			}
+
+			if renderer.frames < 29 || renderer.frames > 31 {
+				t.Errorf("rendered %d frames in 30 ticks", renderer.frames)
+			}
+
+			if c8.CycleCount() != 3 {
+				t.Errorf("ran %d cycles, want 3 before halting", c8.CycleCount())
+			}
+		})
+	}
+}
+
+func TestLongLoad(t *testing.T) {
+	tests := []struct {
+		name     string
+		extended bool
+		wantPC   uint16
+		wantI    uint16
+		err      error
+	}{
+		{"XO-CHIP", true, 0x204, 0x1234, nil},
+		{"CHIP-8", false, 0x202, 0x0000, ErrUnknownOpcode},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c8 := newTestCore(t)
+			c8.SetExtendedMemory(tt.extended)
+			c8.loadROM([]byte{0xF0, 0x00, 0x12, 0x34})
+
+			_, err := c8.Step()
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got %v, want %v", err, tt.err)
+			}
+
+			if c8.programCounter != tt.wantPC {
+				t.Errorf("PC = 0x%04X, want 0x%04X", c8.programCounter, tt.wantPC)
+			}
+
+			if c8.indexRegister != tt.wantI {
+				t.Errorf("I = 0x%04X, want 0x%04X", c8.indexRegister, tt.wantI)
+			}
+		})
+	}
+}
+
+func TestLongLoadAtEndOfMemory(t *testing.T) {
+	c8 := newTestCore(t)
+	c8.SetExtendedMemory(true)
+	c8.LoadROMAt([]byte{0xF0, 0x00}, 0xFFFE)
+	c8.SetProgramCounter(0xFFFE)
+
+	_, err := c8.Step()
+	if !errors.Is(err, ErrOutOfBounds) {
+		t.Fatalf("got %v, want ErrOutOfBounds", err)
+	}
+}
+
+func TestSkipOverLongLoad(t *testing.T) {
+	tests := []struct {
+		name     string
+		extended bool
+		skip     []byte
+		wantPC   uint16
+	}{
+		// 3000 skips with V0 = 0, 4000 and 9000 don't
+		{"3xkk over F000 with XO-CHIP", true, []byte{0x30, 0x00}, 0x206},
+		{"3xkk over F000 in CHIP-8 mode", false, []byte{0x30, 0x00}, 0x204},
+		{"5xy0 over F000 with XO-CHIP", true, []byte{0x50, 0x10}, 0x206},
+		{"ExA1 over F000 with XO-CHIP", true, []byte{0xE0, 0xA1}, 0x206},
+		{"no skip", true, []byte{0x40, 0x00}, 0x202},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c8 := newTestCore(t)
+			c8.SetExtendedMemory(tt.extended)
+			c8.loadROM(append(tt.skip, 0xF0, 0x00, 0x12, 0x34))
+
+			stepN(t, c8, 1)
+
+			if c8.programCounter != tt.wantPC {
+				t.Errorf("PC = 0x%04X, want 0x%04X", c8.programCounter, tt.wantPC)
+			}
+		})
+	}
+}
+
+func TestAutoGrowMemory(t *testing.T) {
+	tests := []struct {
+		name     string
+		autoGrow bool
+		wantSize int
+		err      error
+	}{
+		{"enabled", true, EXTENDED_MEMORY_SIZE, nil},
+		{"disabled", false, MEMORY_SIZE, ErrUnknownOpcode},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 6007 F000 1234 F065: a normal instruction, then the long load and a read from far memory
+			c8 := newTestCore(t, 0x60, 0x07, 0xF0, 0x00, 0x12, 0x34, 0xF0, 0x65)
+			c8.SetAutoGrowMemory(tt.autoGrow)
+			stepN(t, c8, 1)
+
+			_, err := c8.Step()
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got %v, want %v", err, tt.err)
+			}
+
+			if len(c8.memory) != tt.wantSize || len(c8.executed) != tt.wantSize {
+				t.Errorf("memory is %d bytes, want %d", len(c8.memory), tt.wantSize)
+			}
+
+			if tt.err != nil {
+				return
+			}
+
+			if c8.indexRegister != 0x1234 || c8.programCounter != 0x206 {
+				t.Errorf("I = 0x%04X, PC = 0x%04X after the long load", c8.indexRegister, c8.programCounter)
+			}
+
+			// Contents survive the move, and the grown memory is usable
+			if c8.registers[0] != 0x07 || c8.memory[0x200] != 0x60 || c8.memory[FONTSET_START_ADDRESS] != 0xF0 {
+				t.Error("memory contents were lost growing memory")
+			}
+
+			c8.memory[0x1234] = 0x99
+			stepN(t, c8, 1)
+
+			if c8.registers[0] != 0x99 {
+				t.Errorf("V0 = 0x%02X after loading from 0x1234, want 0x99", c8.registers[0])
+			}
+		})
+	}
+}
+
+func TestKeypadClearing(t *testing.T) {
+	tests := []struct {
+		name              string
+		onPause, onResume bool
+		heldWhilePaused   bool
+		pressedAfter      bool
+	}{
+		{"keep", false, false, true, true},
+		{"on pause", true, false, false, false},
+		{"on resume", false, true, true, false},
+		{"both", true, true, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// LD V5, 5; SKP V5
+			c8 := newTestCore(t, 0x65, 0x05, 0xE5, 0x9E)
+			c8.SetKeypadClearing(tt.onPause, tt.onResume)
+			c8.keypad[5] = 1
+
+			c8.Pause()
+			if held := c8.keypad[5] != 0; held != tt.heldWhilePaused {
+				t.Errorf("key held while paused = %v, want %v", held, tt.heldWhilePaused)
+			}
+
+			c8.Resume()
+			stepN(t, c8, 2)
+
+			want := uint16(0x204)
+			if tt.pressedAfter {
+				want = 0x206
+			}
+			if c8.programCounter != want {
+				t.Errorf("PC = 0x%04X after SKP on resume, want 0x%04X", c8.programCounter, want)
+			}
+		})
+	}
+}
+
+func TestDrawsPerFrame(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit int
+		want  int
+	}{
+		{"unlimited", 0, 10},
+		{"one", 1, 1},
+		{"three", 3, 3},
+		{"above what's drawn", 20, 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// DRW V0, V0, 1; ADD V1, 1; JP 0x200: V1 counts the draws that went through
+			c8 := newTestCore(t, 0xD0, 0x01, 0x71, 0x01, 0x12, 0x00)
+			c8.SetCyclesPerFrame(30)
+			c8.SetDrawsPerFrame(tt.limit)
+
+			for frame := range 5 {
+				before := c8.registers[1]
+				if err := c8.runFrame(); err != nil {
+					t.Fatal(err)
+				}
+
+				if draws := int(c8.registers[1] - before); draws != tt.want {
+					t.Fatalf("frame %d had %d draws, want %d", frame, draws, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestRunConformanceKeepsOpcodeStats(t *testing.T) {
+	tests := []struct {
+		name    string
+		rom     []byte
+		wantErr bool
+		want    map[string]uint64
+	}{
+		{"clean run", []byte{0x60, 0x01, 0x12, 0x02}, false, map[string]uint64{"6xkk": 1, "1nnn": 9}},
+		{"crash", []byte{0x60, 0x01, 0xFF, 0xFF}, true, map[string]uint64{"6xkk": 1, "unknown": 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c8 := newTestCore(t, tt.rom...)
+
+			_, err := c8.RunConformance(10)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RunConformance() error = %v, want error %v", err, tt.wantErr)
+			}
+
+			if got := c8.OpcodeCounts(); !maps.Equal(got, tt.want) {
+				t.Errorf("opcode counts = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIntegerScale(t *testing.T) {
+	tests := []struct {
+		name          string
+		width, height int32
+		scale         int32
+		viewport      sdl.Rect
+	}{
+		{"exact fit", 640, 320, 10, sdl.Rect{X: 0, Y: 0, W: 640, H: 320}},
+		{"wider window", 1000, 320, 10, sdl.Rect{X: 180, Y: 0, W: 640, H: 320}},
+		{"taller window", 640, 500, 10, sdl.Rect{X: 0, Y: 90, W: 640, H: 320}},
+		{"between multiples", 700, 350, 10, sdl.Rect{X: 30, Y: 15, W: 640, H: 320}},
+		{"smaller than the display", 40, 20, 1, sdl.Rect{X: -12, Y: -6, W: 64, H: 32}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scale, viewport := integerScale(tt.width, tt.height)
+
+			if scale != tt.scale || viewport != tt.viewport {
+				t.Errorf("integerScale(%d, %d) = %d, %+v, want %d, %+v", tt.width, tt.height, scale, viewport, tt.scale, tt.viewport)
+			}
+		})
+	}
+}
+
+func TestAdjustCycleDelay(t *testing.T) {
+	tests := []struct {
+		name  string
+		start float64
+		delta float64
+		times int
+		want  float64
+	}{
+		{"faster", 5, -CYCLE_DELAY_STEP, 2, 3},
+		{"slower", 5, CYCLE_DELAY_STEP, 3, 8},
+		{"stops at the minimum", 1, -CYCLE_DELAY_STEP, 5, MIN_CYCLE_DELAY},
+		{"stops at the maximum", MAX_CYCLE_DELAY - 1, CYCLE_DELAY_STEP, 5, MAX_CYCLE_DELAY},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c8 := newCore(10, tt.start)
+
+			for range tt.times {
+				c8.adjustCycleDelay(tt.delta)
+			}
+
+			if c8.cycleDelay != tt.want {
+				t.Errorf("cycle delay = %vms, want %vms", c8.cycleDelay, tt.want)
+			}
+
+			// The new delay paces the very next cycle
+			if want := time.Duration(tt.want * float64(time.Millisecond)); c8.cycleInterval() != want {
+				t.Errorf("cycle interval = %v, want %v", c8.cycleInterval(), want)
+			}
+		})
+	}
+}
+
+func TestShiftCarry(t *testing.T) {
+	tests := []struct {
+		name   string
+		opcode uint16
+		x      byte
+		value  byte
+		want   byte
+		carry  byte
+	}{
+		{"SHR", 0x8106, 0x1, 0x05, 0x02, 1},
+		{"SHR without carry", 0x8106, 0x1, 0x04, 0x02, 0},
+		{"SHL", 0x810E, 0x1, 0x81, 0x02, 1},
+		{"SHL without carry", 0x810E, 0x1, 0x41, 0x82, 0},
+		// Shifting VF itself: the carry wins over the shifted value
+		{"SHR VF", 0x8F06, 0xF, 0x03, 1, 1},
+		{"SHR VF without carry", 0x8F06, 0xF, 0x40, 0, 0},
+		{"SHL VF", 0x8F0E, 0xF, 0x81, 1, 1},
+		{"SHL VF without carry", 0x8F0E, 0xF, 0x7F, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c8 := newTestCore(t, byte(tt.opcode>>8), byte(tt.opcode))
+			c8.registers[tt.x] = tt.value
+			stepN(t, c8, 1)
+
+			if c8.registers[tt.x] != tt.want {
+				t.Errorf("V%X = 0x%02X, want 0x%02X", tt.x, c8.registers[tt.x], tt.want)
+			}
+			if c8.registers[0xF] != tt.carry {
+				t.Errorf("VF = %d, want %d", c8.registers[0xF], tt.carry)
+			}
+		})
+	}
+}
+
+func TestVFPoison(t *testing.T) {
+	tests := []struct {
+		name   string
+		opcode uint16
+		poison bool
+		result byte
+		vf     byte
+	}{
+		{"OR", 0x8121, true, 0x0F | 0x3C, VF_POISON},
+		{"AND", 0x8122, true, 0x0F & 0x3C, VF_POISON},
+		{"XOR", 0x8123, true, 0x0F ^ 0x3C, VF_POISON},
+		{"OR unpoisoned", 0x8121, false, 0x0F | 0x3C, 0x77},
+		{"ADD defines VF", 0x8124, true, 0x0F + 0x3C, 0},
+		{"OR into VF keeps the result", 0x8F21, true, 0x77 | 0x3C, 0x77 | 0x3C},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c8 := newTestCore(t, byte(tt.opcode>>8), byte(tt.opcode))
+			c8.SetVFPoison(tt.poison)
+			c8.registers[1] = 0x0F
+			c8.registers[2] = 0x3C
+			c8.registers[0xF] = 0x77
+			stepN(t, c8, 1)
+
+			x := (tt.opcode & 0x0F00) >> 8
+			if c8.registers[x] != tt.result {
+				t.Errorf("V%X = 0x%02X, want 0x%02X", x, c8.registers[x], tt.result)
+			}
+			if c8.registers[0xF] != tt.vf {
+				t.Errorf("VF = 0x%02X, want 0x%02X", c8.registers[0xF], tt.vf)
+			}
+		})
+	}
+}
+
+func TestScaledFramebuffer(t *testing.T) {
+	tests := []struct {
+		name  string
+		hires bool
+		scale int
+		want  int
+	}{
+		{"unscaled", false, 1, 1},
+		{"scaled", false, 3, 3},
+		{"zero is unscaled", false, 0, 1},
+		{"high-res", true, 2, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c8 := newTestCore(t)
+			c8.setHires(tt.hires)
+			c8.FillDisplayPattern(func(x, y int) bool { return (x+y)%3 == 0 })
+
+			buffer := c8.ScaledFramebuffer(tt.scale)
+			width, height := c8.Resolution()
+			if len(buffer) != height*tt.want || len(buffer[0]) != width*tt.want {
+				t.Fatalf("buffer is %dx%d, want %dx%d", len(buffer[0]), len(buffer), width*tt.want, height*tt.want)
+			}
+
+			for y, row := range buffer {
+				for x, color := range row {
+					if want := c8.GetPixel(x/tt.want, y/tt.want); color != want {
+						t.Fatalf("pixel (%d, %d) = 0x%08X, want 0x%08X", x, y, color, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestLoadROMAt(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		addr    uint16
+		wantErr bool
+	}{
+		{"overlay", []byte{0x60, 0x2A, 0x12, 0x02}, 0x600, false},
+		{"fills the end of memory", []byte{0x60, 0x2A, 0x12, 0x02}, MEMORY_SIZE - 4, false},
+		{"past the end of memory", []byte{0x60, 0x2A, 0x12, 0x02}, MEMORY_SIZE - 3, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c8 := newTestCore(t)
+			before := c8.Memory()
+
+			err := c8.LoadROMAt(tt.data, tt.addr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadROMAt() error = %v, want error %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				if !slices.Equal(c8.Memory(), before) {
+					t.Error("a failed load changed memory")
+				}
+				return
+			}
+
+			if err := c8.SetProgramCounter(tt.addr); err != nil {
+				t.Fatal(err)
+			}
+			stepN(t, c8, 1)
+			if c8.registers[0] != 0x2A {
+				t.Errorf("V0 = 0x%02X after running from 0x%04X, want 0x2A", c8.registers[0], tt.addr)
+			}
+		})
+	}
+}
+
+func TestSetProgramCounter(t *testing.T) {
+	c8 := newTestCore(t)
+
+	for _, addr := range []uint16{MEMORY_SIZE - 1, MEMORY_SIZE, 0xFFFF} {
+		if err := c8.SetProgramCounter(addr); err == nil {
+			t.Errorf("SetProgramCounter(0x%04X) was accepted", addr)
+		}
+	}
+
+	if c8.programCounter != uint16(START_ADDRESS) {
+		t.Errorf("rejected addresses moved the program counter to 0x%04X", c8.programCounter)
+	}
+}
+
+func TestInstructionFilter(t *testing.T) {
+	// LD V0, 1; LD V1, 2; LD V2, 3
+	rom := []byte{0x60, 0x01, 0x61, 0x02, 0x62, 0x03}
+
+	tests := []struct {
+		name   string
+		filter func(pc, opcode uint16) (uint16, bool)
+		want   [3]byte
+	}{
+		{"pass through", func(pc, opcode uint16) (uint16, bool) { return opcode, false }, [3]byte{1, 2, 3}},
+		{"veto", func(pc, opcode uint16) (uint16, bool) { return opcode, pc == 0x202 }, [3]byte{1, 0, 3}},
+		{"rewrite", func(pc, opcode uint16) (uint16, bool) {
+			if opcode == 0x6203 {
+				return 0x62FF, false
+			}
+			return opcode, false
+		}, [3]byte{1, 2, 0xFF}},
+		{"removed", nil, [3]byte{1, 2, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c8 := newTestCore(t, rom...)
+			seen := []uint16{}
+			c8.SetInstructionFilter(func(pc, opcode uint16) (uint16, bool) {
+				seen = append(seen, pc)
+				return tt.filter(pc, opcode)
+			})
+			if tt.filter == nil {
+				c8.SetInstructionFilter(nil)
+			}
+			stepN(t, c8, 3)
+
+			if got := [3]byte(c8.registers[:3]); got != tt.want {
+				t.Errorf("V0-V2 = % X, want % X", got, tt.want)
+			}
+
+			// Vetoed instructions still move the program counter on
+			if c8.programCounter != 0x206 {
+				t.Errorf("PC = 0x%04X, want 0x0206", c8.programCounter)
+			}
+
+			if tt.filter != nil && !slices.Equal(seen, []uint16{0x200, 0x202, 0x204}) {
+				t.Errorf("filter saw addresses % X", seen)
+			}
+		})
+	}
+}
+
+func TestInstructionFilterSkipsLongInstruction(t *testing.T) {
+	// F000 0x6205 (LD I, long) is vetoed, then LD V3, 7; without extended memory 0x6205 runs as LD V2, 5
+	rom := []byte{0xF0, 0x00, 0x62, 0x05, 0x63, 0x07}
+
+	tests := []struct {
+		name     string
+		extended bool
+		pc       uint16
+		v2, v3   byte
+	}{
+		{"extended memory steps over the address", true, 0x204, 0, 7},
+		{"standard memory runs the next two bytes", false, 0x202, 5, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c8 := newTestCore(t, rom...)
+			c8.SetExtendedMemory(tt.extended)
+			c8.SetInstructionFilter(func(pc, opcode uint16) (uint16, bool) { return opcode, pc == 0x200 })
+
+			stepN(t, c8, 1)
+			if c8.programCounter != tt.pc {
+				t.Errorf("PC = 0x%04X after the vetoed F000, want 0x%04X", c8.programCounter, tt.pc)
+			}
+			if c8.indexRegister != 0 {
+				t.Errorf("I = 0x%04X, want it untouched", c8.indexRegister)
+			}
+
+			stepN(t, c8, 1)
+			if c8.registers[2] != tt.v2 || c8.registers[3] != tt.v3 {
+				t.Errorf("V2, V3 = %d, %d, want %d, %d", c8.registers[2], c8.registers[3], tt.v2, tt.v3)
+			}
+		})
+	}
+}
+
+func TestSkipIfKeyMasksRegister(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   byte
+		pressed int
+		op      byte
+		skipped bool
+	}{
+		{"SKP 0xFF with F pressed", 0xFF, 0xF, 0x9E, true},
+		{"SKP 0xFF with nothing pressed", 0xFF, -1, 0x9E, false},
+		{"SKP 0x13 with 3 pressed", 0x13, 0x3, 0x9E, true},
+		{"SKP 0x13 with 1 pressed", 0x13, 0x1, 0x9E, false},
+		{"SKNP 0xFF with F pressed", 0xFF, 0xF, 0xA1, false},
+		{"SKNP 0xFF with nothing pressed", 0xFF, -1, 0xA1, true},
+		{"SKNP 0xA0 with 0 pressed", 0xA0, 0x0, 0xA1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// LD V7, value; SKP/SKNP V7
+			c8 := newTestCore(t, 0x67, tt.value, 0xE7, tt.op)
+			if tt.pressed >= 0 {
+				c8.keypad[tt.pressed] = 1
+			}
+			stepN(t, c8, 2)
+
+			want := uint16(0x204)
+			if tt.skipped {
+				want = 0x206
+			}
+			if c8.programCounter != want {
+				t.Errorf("PC = 0x%04X, want 0x%04X", c8.programCounter, want)
+			}
+		})
+	}
+}
+
+func TestDxynDraw(t *testing.T) {
+	type point struct{ x, y int }
+
+	tests := []struct {
+		name   string
+		vx, vy byte
+		lit    []point
+	}{
+		{"origin", 0, 0, []point{{0, 0}, {1, 0}, {7, 0}, {0, 1}}},
+		{"middle", 10, 5, []point{{10, 5}, {11, 5}, {17, 5}, {10, 6}}},
+		{"wraps right edge", 60, 3, []point{{60, 3}, {61, 3}, {3, 3}, {60, 4}}},
+		{"wraps both edges", 63, 31, []point{{63, 31}, {0, 31}, {6, 31}, {63, 0}}},
+		{"start off screen", 70, 40, []point{{6, 8}, {7, 8}, {13, 8}, {6, 9}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// LD V0, vx; LD V1, vy; LD I, 0x20C; DRW V0, V1, 2 twice; then the sprite 11000001 10000000
+			c8 := newTestCore(t, 0x60, tt.vx, 0x61, tt.vy, 0xA2, 0x0C, 0xD0, 0x12, 0xD0, 0x12, 0x12, 0x0A, 0xC1, 0x80)
+			stepN(t, c8, 4)
+
+			plane := c8.plane(0)
+			lit := 0
+			for _, v := range plane {
+				if v != 0 {
+					lit++
+				}
+			}
+			if lit != len(tt.lit) {
+				t.Errorf("%d pixels lit, want %d", lit, len(tt.lit))
+			}
+
+			for _, p := range tt.lit {
+				if plane[p.y*VIDEO_WIDTH+p.x] == 0 {
+					t.Errorf("pixel (%d, %d) not lit", p.x, p.y)
+				}
+			}
+
+			if c8.registers[0xF] != 0 {
+				t.Errorf("VF = %d after drawing on a blank screen, want 0", c8.registers[0xF])
+			}
+
+			// Drawing the same sprite again erases it and collides
+			stepN(t, c8, 1)
+			if slices.ContainsFunc(plane[:], func(v uint32) bool { return v != 0 }) {
+				t.Error("pixels still lit after drawing the sprite again")
+			}
+
+			if c8.registers[0xF] != 1 {
+				t.Errorf("VF = %d after redrawing the sprite, want 1", c8.registers[0xF])
+			}
+		})
+	}
+}
+
+func TestLoadedROM(t *testing.T) {
+	tests := []struct {
+		name string
+		rom  []byte
+	}{
+		{"nothing loaded", nil},
+		{"one instruction", []byte{0x12, 0x00}},
+		{"odd length", []byte{0x60, 0x01, 0x12, 0x02, 0xAB}},
+		{"fills memory", slices.Repeat([]byte{0x12, 0x00}, (MEMORY_SIZE-int(START_ADDRESS))/2)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c8 := newTestCore(t, tt.rom...)
+
+			if c8.ROMSize() != len(tt.rom) {
+				t.Errorf("ROMSize() = %d, want %d", c8.ROMSize(), len(tt.rom))
+			}
+
+			rom := c8.LoadedROM()
+			if !slices.Equal(rom, tt.rom) && len(rom)+len(tt.rom) > 0 {
+				t.Errorf("LoadedROM() = % X, want % X", rom, tt.rom)
+			}
+
+			// The copy is the caller's to change
+			if len(rom) > 0 {
+				rom[0] ^= 0xFF
+				if c8.memory[START_ADDRESS] != tt.rom[0] {
+					t.Error("changing the returned ROM changed memory")
+				}
+			}
+		})
+	}
+}
+
+func TestLoadedROMFollowsMemory(t *testing.T) {
+	// LD V0, 0xEE; LD I, 0x205; LD [I], V0, overwriting the ROM's last byte
+	c8 := newTestCore(t, 0x60, 0xEE, 0xA2, 0x05, 0xF0, 0x55)
+	stepN(t, c8, 3)
+
+	want := []byte{0x60, 0xEE, 0xA2, 0x05, 0xF0, 0xEE}
+	if rom := c8.LoadedROM(); !slices.Equal(rom, want) {
+		t.Errorf("LoadedROM() = % X, want % X", rom, want)
+	}
+}
+
+func TestClearDisplayOnReset(t *testing.T) {
+	tests := []struct {
+		name      string
+		set       func(c8 *chip8)
+		preserved bool
+	}{
+		{"default", func(c8 *chip8) {}, false},
+		{"on", func(c8 *chip8) { c8.SetClearDisplayOnReset(true) }, false},
+		{"off", func(c8 *chip8) { c8.SetClearDisplayOnReset(false) }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// LD V0, 5; LD I, 0x050; DRW V0, V0, 5
+			c8 := newTestCore(t, 0x60, 0x05, 0xA0, 0x50, 0xD0, 0x05)
+			tt.set(c8)
+			stepN(t, c8, 3)
+			drawn := c8.pixels
+
+			err := c8.Reset(true)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if preserved := c8.pixels == drawn; preserved != tt.preserved {
+				t.Errorf("display preserved = %t, want %t", preserved, tt.preserved)
+			}
+
+			if !tt.preserved && slices.ContainsFunc(c8.pixels[:], func(v uint32) bool { return v != 0 }) {
+				t.Error("pixels still lit after resetting")
+			}
+
+			// The CPU is reset either way
+			if c8.programCounter != uint16(START_ADDRESS) || c8.registers[0] != 0 || c8.indexRegister != 0 {
+				t.Errorf("PC = 0x%04X, V0 = %d, I = 0x%04X after resetting", c8.programCounter, c8.registers[0], c8.indexRegister)
+			}
+		})
+	}
+}
+
+func TestCxkkAssignsMaskedRandom(t *testing.T) {
+	tests := []struct {
+		name string
+		kk   byte
+	}{
+		{"full byte", 0xFF},
+		{"low nibble", 0x0F},
+		{"sparse mask", 0x81},
+		{"zero mask", 0x00},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// LD V3, 0xFF; then RND V3, kk repeatedly
+			c8 := newTestCore(t, 0x63, 0xFF, 0xC3, tt.kk, 0xC3, tt.kk, 0xC3, tt.kk, 0xC3, tt.kk)
+			c8.SetRandomSeed(42)
+			stepN(t, c8, 1)
+
+			// A second machine with the same seed predicts each random byte
+			reference := newCore(10, 0)
+			reference.SetRandomSeed(42)
+
+			for range 4 {
+				want := reference.randomByte() & tt.kk
+				stepN(t, c8, 1)
+
+				if c8.registers[3] != want {
+					t.Fatalf("V3 = 0x%02X, want 0x%02X", c8.registers[3], want)
+				}
+			}
+		})
+	}
+}
+
+func TestRandomByteRange(t *testing.T) {
+	tests := []struct {
+		name   string
+		seeded bool
+		seed   uint64
+	}{
+		{"unseeded", false, 0},
+		{"seed 0", true, 0},
+		{"seed 1", true, 1},
+		{"seed 0xDEADBEEF", true, 0xDEADBEEF},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c8 := newTestCore(t)
+			if tt.seeded {
+				c8.SetRandomSeed(tt.seed)
+			}
+
+			// 256 values over 10000 draws: every one turns up, including both ends
+			seen := [256]bool{}
+			for range 10000 {
+				seen[c8.randomByte()] = true
+			}
+
+			for v, ok := range seen {
+				if !ok {
+					t.Errorf("0x%02X never returned", v)
+				}
+			}
+		})
+	}
+}
+
+func TestRandomSeedRepeats(t *testing.T) {
+	sequence := func(seed uint64) []byte {
+		c8 := newTestCore(t)
+		c8.SetRandomSeed(seed)
+
+		values := make([]byte, 16)
+		for k := range values {
+			values[k] = c8.randomByte()
+		}
+
+		return values
+	}
+
+	if a, b := sequence(7), sequence(7); !slices.Equal(a, b) {
+		t.Errorf("seed 7 gave % X then % X", a, b)
+	}
+
+	if a, b := sequence(7), sequence(8); slices.Equal(a, b) {
+		t.Errorf("seeds 7 and 8 both gave % X", a)
+	}
+}
+
+func TestNewChip8(t *testing.T) {
+	tests := []struct {
+		name       string
+		videoScale int
+		cycleDelay float64
+	}{
+		{"defaults from main", 10, 3},
+		{"small and fast", 1, 0},
+		{"fractional delay", 4, 0.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c8, err := NewChip8(tt.videoScale, tt.cycleDelay, Headless())
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer c8.Destroy()
+
+			if c8.videoScale != tt.videoScale || c8.cycleDelay != tt.cycleDelay {
+				t.Errorf("video scale %d and cycle delay %v, want %d and %v", c8.videoScale, c8.cycleDelay, tt.videoScale, tt.cycleDelay)
+			}
+
+			if want := (sdl.Rect{W: int32(VIDEO_WIDTH * tt.videoScale), H: int32(VIDEO_HEIGHT * tt.videoScale)}); c8.viewport != want {
+				t.Errorf("viewport = %v, want %v", c8.viewport, want)
+			}
+		})
+	}
+}
+
+func TestSDLInitError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{"missing library", errors.New("could not load libSDL2")},
+		{"no video device", errors.New("no available video device")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := sdlInitError(tt.err)
+
+			if !errors.Is(err, tt.err) || errors.Unwrap(err) != tt.err {
+				t.Errorf("%v doesn't wrap the SDL error", err)
+			}
+
+			for _, guidance := range []string{tt.err.Error(), "install SDL2 as described in the README", "-conformance"} {
+				if !strings.Contains(err.Error(), guidance) {
+					t.Errorf("%q doesn't mention %q", err, guidance)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadChip8ROM(t *testing.T) {
+	dir := t.TempDir()
+	romFile := filepath.Join(dir, "counter.ch8")
+	rom := []byte{0x70, 0x01, 0x12, 0x00}
+	if err := os.WriteFile(romFile, rom, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		file    string
+		wantErr bool
+	}{
+		{"ROM file", romFile, false},
+		{"missing file", filepath.Join(dir, "missing.ch8"), true},
+		{"directory", dir, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c8, err := NewChip8(1, 0, Headless())
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer c8.Destroy()
+
+			err = c8.LoadChip8ROM(tt.file)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadChip8ROM() error = %v, want error %t", err, tt.wantErr)
+			}
+
+			if !tt.wantErr && !slices.Equal(c8.LoadedROM(), rom) {
+				t.Errorf("loaded % X, want % X", c8.LoadedROM(), rom)
+			}
+		})
+	}
+}
+
+func TestLoadROMSizeLimit(t *testing.T) {
+	available := MEMORY_SIZE - int(START_ADDRESS)
+
+	tests := []struct {
+		name     string
+		size     int
+		extended bool
+		wantErr  bool
+	}{
+		{"fits exactly", available, false, false},
+		{"one byte over", available + 1, false, true},
+		{"far over", 2 * MEMORY_SIZE, false, true},
+		{"fits in extended memory", available + 1, true, false},
+		{"over extended memory", EXTENDED_MEMORY_SIZE - int(START_ADDRESS) + 1, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c8 := newTestCore(t)
+			c8.SetExtendedMemory(tt.extended)
+
+			err := c8.LoadROMBytes(slices.Repeat([]byte{0xAB}, tt.size))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loading %d bytes: error = %v, want error %t", tt.size, err, tt.wantErr)
+			}
+
+			// A rejected ROM leaves memory as it was
+			want := tt.size
+			if tt.wantErr {
+				want = 0
+			}
+			if c8.ROMSize() != want || (want == 0) != (c8.memory[START_ADDRESS] == 0) {
+				t.Errorf("ROMSize() = %d with 0x%02X at the start address, want %d", c8.ROMSize(), c8.memory[START_ADDRESS], want)
+			}
+		})
+	}
+}
+
+func TestDestroyTwice(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func() (*chip8, error)
+	}{
+		{"headless", func() (*chip8, error) { return NewChip8(1, 0, Headless()) }},
+		{"core only", func() (*chip8, error) { return newCore(10, 0), nil }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c8, err := tt.build()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			c8.Destroy()
+			c8.Destroy()
+
+			if c8.window != nil || c8.surface != nil || c8.audioDevice != 0 {
+				t.Error("SDL resources left after Destroy")
+			}
+		})
+	}
+}
+
+func TestTimerDecrementOrder(t *testing.T) {
+	tests := []struct {
+		name  string
+		order TimerDecrementOrder
+		read  byte
+	}{
+		{"before", TimersBeforeCycle, 4},
+		{"after", TimersAfterCycle, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// LD V0, 5; LD DT, V0; ADD V2, 1 until LD V1, DT lands on the cycle the timers tick
+			rom := []byte{0x60, 0x05, 0xF0, 0x15}
+			for range HEADLESS_CYCLES_PER_TICK - 3 {
+				rom = append(rom, 0x72, 0x01)
+			}
+			c8 := newTestCore(t, append(rom, 0xF1, 0x07)...)
+			c8.SetTimerDecrementOrder(tt.order)
+
+			for range HEADLESS_CYCLES_PER_TICK {
+				if err := c8.headlessCycle(); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if c8.registers[1] != tt.read {
+				t.Errorf("Fx07 read %d, want %d", c8.registers[1], tt.read)
+			}
+
+			// Either way the timer ticked once
+			if c8.delayTimer != 4 {
+				t.Errorf("delay timer %d after the tick, want 4", c8.delayTimer)
+			}
+		})
+	}
+}
+
+func TestLoadStoreFullIndex(t *testing.T) {
+	values := []byte{0x11, 0x22, 0x33, 0x44}
+
+	tests := []struct {
+		name string
+		addr uint16
+	}{
+		{"0x300", 0x300},
+		{"across a page", 0x3FE},
+		{"end of memory", MEMORY_SIZE - 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// LD I, addr; LD [I], V3; LD V3, [I]
+			c8 := newTestCore(t, 0xA0|byte(tt.addr>>8), byte(tt.addr), 0xF3, 0x55, 0xF3, 0x65)
+			copy(c8.registers[:], values)
+			stepN(t, c8, 2)
+
+			if got := c8.memory[tt.addr : tt.addr+4]; !slices.Equal(got, values) {
+				t.Errorf("memory at 0x%03X = % X, want % X", tt.addr, got, values)
+			}
+
+			// Nothing landed where an index truncated to 8 bits would point
+			if got := c8.memory[tt.addr&0xFF : tt.addr&0xFF+4]; slices.Equal(got, values) {
+				t.Errorf("registers also stored at 0x%03X", tt.addr&0xFF)
+			}
+
+			clear(c8.registers[:4])
+			c8.indexRegister = tt.addr
+			stepN(t, c8, 1)
+
+			if got := c8.registers[:4]; !slices.Equal(got, values) {
+				t.Errorf("read back V0-V3 = % X, want % X", got, values)
+			}
+		})
+	}
+}
+
+func TestIncrementIndexOnLoadStore(t *testing.T) {
+	tests := []struct {
+		name      string
+		set       func(c8 *chip8)
+		opcode    byte
+		x         byte
+		wantIndex uint16
+	}{
+		{"default Fx55", func(c8 *chip8) {}, 0x55, 0x3, 0x304},
+		{"default Fx65", func(c8 *chip8) {}, 0x65, 0x3, 0x304},
+		{"on Fx55 V0", func(c8 *chip8) { c8.SetIncrementIndexOnLoadStore(true) }, 0x55, 0x0, 0x301},
+		{"on Fx65 VF", func(c8 *chip8) { c8.SetIncrementIndexOnLoadStore(true) }, 0x65, 0xF, 0x310},
+		{"off Fx55", func(c8 *chip8) { c8.SetIncrementIndexOnLoadStore(false) }, 0x55, 0x3, 0x300},
+		{"off Fx65", func(c8 *chip8) { c8.SetIncrementIndexOnLoadStore(false) }, 0x65, 0xF, 0x300},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// LD I, 0x300; then Fx55 or Fx65
+			c8 := newTestCore(t, 0xA3, 0x00, 0xF0|tt.x, tt.opcode)
+			tt.set(c8)
+			stepN(t, c8, 2)
+
+			if c8.indexRegister != tt.wantIndex {
+				t.Errorf("I = 0x%03X, want 0x%03X", c8.indexRegister, tt.wantIndex)
+			}
+		})
+	}
+}
+
+func TestShiftUsesVy(t *testing.T) {
+	tests := []struct {
+		name   string
+		opcode uint16
+		usesVy bool
+		want   byte
+		carry  byte
+	}{
+		// V1 = 0x05, V2 = 0x82
+		{"SHR in place", 0x8126, false, 0x02, 1},
+		{"SHR from Vy", 0x8126, true, 0x41, 0},
+		{"SHL in place", 0x812E, false, 0x0A, 0},
+		{"SHL from Vy", 0x812E, true, 0x04, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c8 := newTestCore(t, byte(tt.opcode>>8), byte(tt.opcode))
+			c8.SetShiftUsesVy(tt.usesVy)
+			c8.registers[1] = 0x05
+			c8.registers[2] = 0x82
+			stepN(t, c8, 1)
+
+			if c8.registers[1] != tt.want {
+				t.Errorf("V1 = 0x%02X, want 0x%02X", c8.registers[1], tt.want)
+			}
+			if c8.registers[0xF] != tt.carry {
+				t.Errorf("VF = %d, want %d", c8.registers[0xF], tt.carry)
+			}
+
+			// Vy is only ever read
+			if c8.registers[2] != 0x82 {
+				t.Errorf("V2 = 0x%02X, want it unchanged", c8.registers[2])
+			}
+		})
+	}
+}
+
+func TestBxnnJumpQuirk(t *testing.T) {
+	tests := []struct {
+		name   string
+		opcode []byte
+		quirk  bool
+		wantPC uint16
+	}{
+		// V0 = 0x10, V3 = 0x20
+		{"Bnnn uses V0", []byte{0xB3, 0x40}, false, 0x350},
+		{"Bxnn uses Vx", []byte{0xB3, 0x40}, true, 0x360},
+		{"Bxnn with x = 0", []byte{0xB0, 0x40}, true, 0x050},
+		{"Bnnn with x = 0", []byte{0xB0, 0x40}, false, 0x050},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c8 := newTestCore(t, tt.opcode...)
+			c8.SetBxnnJumpQuirk(tt.quirk)
+			c8.registers[0] = 0x10
+			c8.registers[3] = 0x20
+			stepN(t, c8, 1)
+
+			if c8.programCounter != tt.wantPC {
+				t.Errorf("PC = 0x%04X, want 0x%04X", c8.programCounter, tt.wantPC)
+			}
+		})
+	}
+}
+
+func TestHiresMode(t *testing.T) {
+	tests := []struct {
+		name          string
+		rom           []byte
+		width, height int
+	}{
+		{"power on", []byte{}, VIDEO_WIDTH, VIDEO_HEIGHT},
+		{"00FF", []byte{0x00, 0xFF}, HIRES_VIDEO_WIDTH, HIRES_VIDEO_HEIGHT},
+		{"00FF then 00FE", []byte{0x00, 0xFF, 0x00, 0xFE}, VIDEO_WIDTH, VIDEO_HEIGHT},
+		{"00FE while low-res", []byte{0x00, 0xFE}, VIDEO_WIDTH, VIDEO_HEIGHT},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// After switching: LD V0, 100; LD V1, 50; LD I, 0x050; DRW V0, V1, 1
+			rom := append(slices.Clone(tt.rom), 0x60, 0x64, 0x61, 0x32, 0xA0, 0x50, 0xD0, 0x11)
+			c8 := newTestCore(t, rom...)
+			renderer := &captureRenderer{}
+			c8.SetRenderer(renderer)
+			stepN(t, c8, len(tt.rom)/2+4)
+
+			width, height := c8.Resolution()
+			if width != tt.width || height != tt.height {
+				t.Fatalf("resolution %dx%d, want %dx%d", width, height, tt.width, tt.height)
+			}
+
+			// The draw wraps at the active width and height
+			x, y := 100%width, 50%height
+			if c8.pixels[y*width+x] == 0 {
+				t.Errorf("pixel (%d, %d) not lit", x, y)
+			}
+
+			c8.update()
+			if len(renderer.rects) != width*height {
+				t.Errorf("%d pixels rendered, want %d", len(renderer.rects), width*height)
+			}
+		})
+	}
+}
+
+func TestSwitchingResolutionClears(t *testing.T) {
+	tests := []struct {
+		name string
+		rom  []byte
+	}{
+		{"to high-res", []byte{0x00, 0xFF}},
+		{"to low-res", []byte{0x00, 0xFE}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c8 := newTestCore(t, tt.rom...)
+			c8.FillDisplayPattern(func(x, y int) bool { return true })
+			stepN(t, c8, 1)
+
+			if slices.ContainsFunc(c8.pixels[:], func(v uint32) bool { return v != 0 }) {
+				t.Error("pixels still lit after switching resolution")
+			}
+		})
+	}
+}
+
+func TestDxy016x16Sprite(t *testing.T) {
+	tests := []struct {
+		name   string
+		hires  bool
+		vx, vy byte
+		lit    int
+	}{
+		{"high-res", true, 20, 10, 16 * 16},
+		{"low-res draws nothing", false, 20, 10, 0},
+		{"wraps in high-res", true, 120, 60, 16 * 16},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// LD V0, vx; LD V1, vy; LD I, 0x20E; DRW V0, V1, 0 twice; JP to itself; then a solid 16x16 block
+			rom := []byte{0x60, tt.vx, 0x61, tt.vy, 0xA2, 0x0E, 0xD0, 0x10, 0xD0, 0x10, 0x12, 0x0A, 0x00, 0x00}
+			rom = append(rom, slices.Repeat([]byte{0xFF}, 32)...)
+			c8 := newTestCore(t, rom...)
+			c8.setHires(tt.hires)
+			stepN(t, c8, 4)
+
+			width, height := c8.Resolution()
+			lit := 0
+			for k, v := range c8.pixels[:width*height] {
+				if v == 0 {
+					continue
+				}
+				lit++
+
+				// Every lit pixel is inside the block, measured from its corner with wrapping
+				x, y := (k%width-int(tt.vx)+width)%width, (k/width-int(tt.vy)+height)%height
+				if x >= 16 || y >= 16 {
+					t.Errorf("pixel (%d, %d) lit outside the block", k%width, k/width)
+				}
+			}
+
+			if lit != tt.lit {
+				t.Errorf("%d pixels lit, want %d", lit, tt.lit)
+			}
+			if c8.registers[0xF] != 0 {
+				t.Errorf("VF = %d after drawing on a blank screen, want 0", c8.registers[0xF])
+			}
+
+			// Drawing it again erases the block, and only collides if something was drawn
+			stepN(t, c8, 1)
+			if slices.ContainsFunc(c8.pixels[:], func(v uint32) bool { return v != 0 }) {
+				t.Error("pixels still lit after drawing the block again")
+			}
+			if want := byte(min(tt.lit, 1)); c8.registers[0xF] != want {
+				t.Errorf("VF = %d after redrawing the block, want %d", c8.registers[0xF], want)
+			}
+		})
+	}
+}
+
+func TestScrollOpcodes(t *testing.T) {
+	type point struct{ x, y int }
+
+	tests := []struct {
+		name   string
+		opcode []byte
+		hires  bool
+		lit    []point
+		want   []point
+	}{
+		{"00C3 down", []byte{0x00, 0xC3}, false, []point{{10, 10}, {5, 30}}, []point{{10, 13}}},
+		{"00C0 no-op", []byte{0x00, 0xC0}, false, []point{{10, 10}}, []point{{10, 10}}},
+		{"00FB right", []byte{0x00, 0xFB}, false, []point{{10, 10}, {62, 0}}, []point{{14, 10}}},
+		{"00FC left", []byte{0x00, 0xFC}, false, []point{{10, 10}, {1, 5}}, []point{{6, 10}}},
+		{"00CF down in high-res", []byte{0x00, 0xCF}, true, []point{{100, 40}, {0, 60}}, []point{{100, 55}}},
+		{"00FB right in high-res", []byte{0x00, 0xFB}, true, []point{{100, 40}, {126, 3}}, []point{{104, 40}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c8 := newTestCore(t, tt.opcode...)
+			c8.setHires(tt.hires)
+			width, _ := c8.Resolution()
+
+			for _, p := range tt.lit {
+				c8.pixels[p.y*width+p.x] = 0xFFFFFFFF
+			}
+			stepN(t, c8, 1)
+
+			// Pixels pushed off the edge are gone rather than wrapped
+			got := []point{}
+			for k, v := range c8.pixels {
+				if v != 0 {
+					got = append(got, point{k % width, k / width})
+				}
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("lit pixels %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFx30BigFont(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     byte
+		wantIndex uint16
+		first     []byte
+	}{
+		{"0", 0x0, 0x0A0, []byte{0xFF, 0xFF, 0xC3}},
+		{"1", 0x1, 0x0AA, []byte{0x18, 0x78, 0x78}},
+		{"C", 0xC, 0x118, []byte{0x3C, 0xFF, 0xC3}},
+		{"F", 0xF, 0x136, []byte{0xFF, 0xFF, 0xC0}},
+		{"high nibble ignored", 0x3F, 0x136, []byte{0xFF, 0xFF, 0xC0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// LD V4, value; LD HF, V4
+			c8 := newTestCore(t, 0x64, tt.value, 0xF4, 0x30)
+			stepN(t, c8, 2)
+
+			if c8.indexRegister != tt.wantIndex {
+				t.Fatalf("I = 0x%03X, want 0x%03X", c8.indexRegister, tt.wantIndex)
+			}
+
+			if got := c8.memory[tt.wantIndex : tt.wantIndex+uint16(len(tt.first))]; !slices.Equal(got, tt.first) {
+				t.Errorf("glyph starts % X, want % X", got, tt.first)
+			}
+		})
+	}
+}
+
+func TestExtendedMemoryAccess(t *testing.T) {
+	tests := []struct {
+		name     string
+		addr     uint16
+		extended bool
+		err      error
+	}{
+		{"past 4k with XO-CHIP", 0x1000, true, nil},
+		{"top of 64k", 0xFFFC, true, nil},
+		{"past 64k", 0xFFFE, true, ErrOutOfBounds},
+		{"past 4k in CHIP-8 mode", 0x1000, false, ErrOutOfBounds},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// LD V2, 0x33; LD [I], V2; LD V2, [I] with I set beforehand, as F000 NNNN would
+			c8 := newTestCore(t, 0x62, 0x33, 0xF2, 0x55, 0xF2, 0x65)
+			c8.SetExtendedMemory(tt.extended)
+			c8.indexRegister = tt.addr
+			stepN(t, c8, 1)
+
+			_, err := c8.Step()
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Fx55 at I = 0x%04X returned %v, want %v", tt.addr, err, tt.err)
+			}
+			if tt.err != nil {
+				return
+			}
+
+			if got := c8.memory[tt.addr+2]; got != 0x33 {
+				t.Errorf("0x%04X = 0x%02X, want 0x33", tt.addr+2, got)
+			}
+
+			clear(c8.registers[:3])
+			c8.indexRegister = tt.addr
+			stepN(t, c8, 1)
+			if c8.registers[2] != 0x33 {
+				t.Errorf("V2 = 0x%02X read back, want 0x33", c8.registers[2])
+			}
+		})
+	}
+}
+
+func TestSetExtendedMemoryKeepsContents(t *testing.T) {
+	c8 := newTestCore(t, 0x12, 0x34)
+
+	c8.SetExtendedMemory(true)
+	if len(c8.memory) != EXTENDED_MEMORY_SIZE {
+		t.Fatalf("%d bytes of memory, want %d", len(c8.memory), EXTENDED_MEMORY_SIZE)
+	}
+	c8.memory[0x8000] = 0xAB
+
+	c8.SetExtendedMemory(false)
+	if len(c8.memory) != MEMORY_SIZE {
+		t.Fatalf("%d bytes of memory, want %d", len(c8.memory), MEMORY_SIZE)
+	}
+
+	// Everything within 4k survives both switches, including the fontset and the ROM
+	if !slices.Equal(c8.LoadedROM(), []byte{0x12, 0x34}) || c8.memory[FONTSET_START_ADDRESS] != 0xF0 {
+		t.Errorf("ROM % X and font byte 0x%02X after growing and shrinking", c8.LoadedROM(), c8.memory[FONTSET_START_ADDRESS])
+	}
+}
+
+func TestReset(t *testing.T) {
+	// LD V5, 9; LD I, 0x300; LD DT, V5; LD ST, V5; LD [I], V5; CALL 0x20E; ...; 7001 120E below
+	rom := []byte{0x65, 0x09, 0xA3, 0x00, 0xF5, 0x15, 0xF5, 0x18, 0xF5, 0x55, 0x22, 0x0E, 0x00, 0x00, 0x70, 0x01, 0x12, 0x0E}
+
+	tests := []struct {
+		name   string
+		reload bool
+		rom    []byte
+	}{
+		{"without the ROM", false, nil},
+		{"reloading the ROM", true, rom},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c8 := newTestCore(t, rom...)
+			stepN(t, c8, 10)
+
+			// The ROM is reloaded as it was loaded, before it overwrote itself
+			c8.memory[START_ADDRESS] = 0xEE
+
+			if err := c8.Reset(tt.reload); err != nil {
+				t.Fatal(err)
+			}
+
+			if c8.programCounter != uint16(START_ADDRESS) || c8.indexRegister != 0 || c8.stackPointer != 0 {
+				t.Errorf("PC 0x%04X, I 0x%04X, SP %d after resetting", c8.programCounter, c8.indexRegister, c8.stackPointer)
+			}
+
+			if c8.registers != [16]byte{} || slices.ContainsFunc(c8.stack, func(v uint16) bool { return v != 0 }) || c8.delayTimer != 0 || c8.soundTimer != 0 {
+				t.Errorf("registers % X, stack % X, timers %d and %d after resetting", c8.registers, c8.stack, c8.delayTimer, c8.soundTimer)
+			}
+
+			if c8.memory[0x300] != 0 || c8.memory[FONTSET_START_ADDRESS] != 0xF0 {
+				t.Errorf("0x300 = 0x%02X and font byte 0x%02X after resetting", c8.memory[0x300], c8.memory[FONTSET_START_ADDRESS])
+			}
+
+			if got := c8.LoadedROM(); !slices.Equal(got, tt.rom) && len(got)+len(tt.rom) > 0 {
+				t.Errorf("loaded ROM % X, want % X", got, tt.rom)
+			}
+		})
+	}
+}
+
+func TestLoadROMReader(t *testing.T) {
+	rom := []byte{0x70, 0x01, 0x12, 0x00}
+	readErr := errors.New("connection reset")
+
+	tests := []struct {
+		name string
+		r    io.Reader
+		want []byte
+		err  error
+	}{
+		{"bytes", bytes.NewReader(rom), rom, nil},
+		{"one byte at a time", iotest.OneByteReader(bytes.NewReader(rom)), rom, nil},
+		{"empty", strings.NewReader(""), []byte{}, nil},
+		{"read error", iotest.ErrReader(readErr), []byte{}, readErr},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c8 := newTestCore(t)
+
+			err := c8.LoadROM(tt.r)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("LoadROM() error = %v, want %v", err, tt.err)
+			}
+
+			if got := c8.LoadedROM(); !slices.Equal(got, tt.want) {
+				t.Errorf("loaded % X, want % X", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadROMBytesCopies(t *testing.T) {
+	rom := []byte{0x70, 0x01, 0x12, 0x00}
+	c8 := newTestCore(t)
+
+	if err := c8.LoadROMBytes(rom); err != nil {
+		t.Fatal(err)
+	}
+	rom[0] = 0xFF
+
+	if c8.memory[START_ADDRESS] != 0x70 {
+		t.Errorf("0x%02X at the start address after reusing the slice, want 0x70", c8.memory[START_ADDRESS])
+	}
+
+	// Reset reloads the ROM as it was given
+	if err := c8.Reset(true); err != nil {
+		t.Fatal(err)
+	}
+	if c8.memory[START_ADDRESS] != 0x70 {
+		t.Errorf("0x%02X at the start address after a reset, want 0x70", c8.memory[START_ADDRESS])
+	}
+}
+
+func TestCyclesPerFrame(t *testing.T) {
+	tests := []struct {
+		name   string
+		cycles int
+		want   int
+	}{
+		{"one", 1, 1},
+		{"typical", 500, 500},
+		{"large", 1000, 1000},
+		{"negative clamps to off", -5, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c8 := newTestCore(t, countingROM...)
+			c8.SetCyclesPerFrame(tt.cycles)
+			c8.delayTimer = 10
+
+			if c8.cyclesPerFrame != tt.want {
+				t.Fatalf("cycles per frame = %d, want %d", c8.cyclesPerFrame, tt.want)
+			}
+
+			for frame := range 3 {
+				before := c8.CycleCount()
+				if err := c8.runFrame(); err != nil {
+					t.Fatal(err)
+				}
+
+				if ran := int(c8.CycleCount() - before); ran != tt.want {
+					t.Errorf("frame %d ran %d cycles, want %d", frame, ran, tt.want)
+				}
+			}
+
+			// One timer tick per frame however many cycles it ran
+			if c8.delayTimer != 7 {
+				t.Errorf("delay timer %d after 3 frames, want 7", c8.delayTimer)
+			}
+		})
+	}
+}
+
+func TestFx0AWaitsForRelease(t *testing.T) {
+	tests := []struct {
+		name string
+		held [][]int
+		pc   uint16
+		v3   byte
+	}{
+		{"no key", [][]int{{}, {}, {}}, 0x200, 0},
+		{"held down", [][]int{{5}, {5}, {5}}, 0x200, 0},
+		{"pressed and released", [][]int{{5}, {}}, 0x202, 5},
+		{"released later", [][]int{{0xA}, {0xA}, {0xA}, {}}, 0x202, 0xA},
+		{"one of two released", [][]int{{5, 7}, {7}}, 0x202, 5},
+		// The second Fx0A waits for a fresh press rather than taking the key released for the first
+		{"second wait", [][]int{{5}, {}, {}, {}}, 0x202, 5},
+		{"second wait pressed again", [][]int{{5}, {}, {6}, {}}, 0x204, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// LD V3, K; LD V4, K; JP to itself
+			c8 := newTestCore(t, 0xF3, 0x0A, 0xF4, 0x0A, 0x12, 0x04)
+
+			for _, keys := range tt.held {
+				clear(c8.keypad[:])
+				for _, k := range keys {
+					c8.keypad[k] = 1
+				}
+				stepN(t, c8, 1)
+			}
+
+			if c8.programCounter != tt.pc || c8.registers[3] != tt.v3 {
+				t.Errorf("PC 0x%04X with V3 = 0x%X, want 0x%04X with 0x%X", c8.programCounter, c8.registers[3], tt.pc, tt.v3)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package emulator
+
+type EventType int
+
+const (
+	// A ROM was loaded into memory
+	EventROMLoaded EventType = iota
+
+	// The program jumped to its own address and will make no further progress
+	EventHalted
+
+	// The emulator hit something it can't recover from and is about to stop
+	EventCrash
+
+	// The machine was put back in its power-on state
+	EventReset
+
+	// Execution was paused or resumed with Pause() and Resume()
+	EventPaused
+	EventResumed
+
+	// RunUntilBreak() stopped at a breakpoint, before the instruction there executes
+	EventBreakpoint
+)
+
+// Lifecycle event handed to subscribers, carrying the machine position it was emitted at
+type Event struct {
+	Type           EventType
+	ProgramCounter uint16
+	Opcode         uint16
+}
+
+// Registers a function to be called for every lifecycle event the emulator emits
+func (c8 *chip8) Subscribe(fn func(Event)) {
+	c8.subscribers = append(c8.subscribers, fn)
+}
+
+func (c8 *chip8) emit(t EventType) {
+	e := Event{Type: t, ProgramCounter: c8.programCounter, Opcode: c8.opcode}
+
+	for _, fn := range c8.subscribers {
+		fn(e)
+	}
+}
@@ -0,0 +1,104 @@
+package emulator
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestEvents(t *testing.T) {
+	tests := []struct {
+		name string
+		rom  []byte
+		run  func(c8 *chip8)
+		want []EventType
+	}{
+		{
+			name: "load, run and halt",
+			// 6001 1202: set V0 then jump to itself
+			rom: []byte{0x60, 0x01, 0x12, 0x02},
+			run: func(c8 *chip8) {
+				c8.SetOnHalt(HaltExit)
+				c8.SetMaxRunTime(framesLimit(10))
+				c8.SetCyclesPerFrame(5)
+				c8.Run()
+			},
+			want: []EventType{EventROMLoaded, EventHalted},
+		},
+		{
+			name: "crash",
+			rom:  []byte{0xFF, 0xFF},
+			run: func(c8 *chip8) {
+				c8.Step()
+			},
+			want: []EventType{EventROMLoaded, EventCrash},
+		},
+		{
+			name: "reset and reload",
+			rom:  []byte{0x60, 0x01},
+			run: func(c8 *chip8) {
+				c8.Reset(true)
+			},
+			want: []EventType{EventROMLoaded, EventReset, EventROMLoaded},
+		},
+		{
+			name: "pause and resume",
+			rom:  []byte{0x60, 0x01},
+			run: func(c8 *chip8) {
+				c8.Pause()
+				c8.Resume()
+				c8.togglePause()
+			},
+			want: []EventType{EventROMLoaded, EventPaused, EventResumed, EventPaused},
+		},
+		{
+			name: "breakpoint",
+			// 6001 6002 6003 1206
+			rom: []byte{0x60, 0x01, 0x60, 0x02, 0x60, 0x03, 0x12, 0x06},
+			run: func(c8 *chip8) {
+				c8.SetBreakpoint(0x204)
+				c8.RunUntilBreak()
+				c8.RunUntilBreak()
+			},
+			want: []EventType{EventROMLoaded, EventBreakpoint, EventHalted},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c8, _ := newClockedCore(t)
+
+			got := []EventType{}
+			c8.Subscribe(func(e Event) {
+				got = append(got, e.Type)
+			})
+
+			err := c8.LoadROMBytes(tt.rom)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			tt.run(c8)
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got events %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEventPosition(t *testing.T) {
+	c8 := newTestCore(t, 0x60, 0x01, 0x12, 0x02)
+
+	var halted Event
+	c8.Subscribe(func(e Event) {
+		if e.Type == EventHalted {
+			halted = e
+		}
+	})
+
+	stepN(t, c8, 2)
+
+	if halted.Opcode != 0x1202 {
+		t.Errorf("halt reported opcode 0x%04X, want 0x1202", halted.Opcode)
+	}
+}
@@ -0,0 +1,27 @@
+package emulator
+
+// Number of recent frames the FPS average is taken over
+const FPS_WINDOW = 60
+
+// Records that a frame was rendered just now, dropping the oldest once the window is full
+func (c8 *chip8) recordFrame() {
+	if len(c8.frameTimes) == FPS_WINDOW {
+		c8.frameTimes = c8.frameTimes[1:]
+	}
+
+	c8.frameTimes = append(c8.frameTimes, c8.clock.Now())
+}
+
+// Returns the rolling average of rendered frames per second over the last FPS_WINDOW frames
+func (c8 *chip8) FPS() float64 {
+	if len(c8.frameTimes) < 2 {
+		return 0
+	}
+
+	elapsed := c8.frameTimes[len(c8.frameTimes)-1].Sub(c8.frameTimes[0])
+	if elapsed <= 0 {
+		return 0
+	}
+
+	return float64(len(c8.frameTimes)-1) / elapsed.Seconds()
+}
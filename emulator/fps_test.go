@@ -0,0 +1,54 @@
+package emulator
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestFPS(t *testing.T) {
+	tests := []struct {
+		name     string
+		frames   int
+		interval time.Duration
+		want     float64
+	}{
+		{"no frames", 0, TIMER_INTERVAL, 0},
+		{"one frame", 1, TIMER_INTERVAL, 0},
+		{"60Hz", 30, time.Second / 60, 60},
+		{"30Hz", 10, time.Second / 30, 30},
+		{"more frames than the window", FPS_WINDOW * 3, time.Second / 50, 50},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c8, clk := newClockedCore(t)
+
+			for range tt.frames {
+				c8.recordFrame()
+				clk.Advance(tt.interval)
+			}
+
+			if got := c8.FPS(); math.Abs(got-tt.want) > 0.01 {
+				t.Errorf("FPS() = %.3f, want %.3f", got, tt.want)
+			}
+			if len(c8.frameTimes) > FPS_WINDOW {
+				t.Errorf("%d frame times kept, more than the window of %d", len(c8.frameTimes), FPS_WINDOW)
+			}
+		})
+	}
+}
+
+func TestFPSWhileRunning(t *testing.T) {
+	c8, _ := newClockedCore(t, 0x70, 0x01, 0x12, 0x00)
+	c8.SetCyclesPerFrame(10)
+	c8.SetMaxRunTime(framesLimit(30))
+
+	if err := c8.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := c8.FPS(); math.Abs(got-60) > 0.5 {
+		t.Errorf("FPS() = %.2f after running at 60Hz", got)
+	}
+}
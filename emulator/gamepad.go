@@ -0,0 +1,111 @@
+package emulator
+
+import (
+	"fmt"
+	"maps"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// Maps game controller buttons to CHIP-8 keypad indices (0x0-0xF)
+type ButtonMap map[sdl.GameControllerButton]byte
+
+/*
+The D-pad drives 2/4/6/8, which most games use for up/left/right/down, and the face buttons cover the keys games
+commonly use for actions.
+*/
+func DefaultButtonMap() ButtonMap {
+	return ButtonMap{
+		sdl.CONTROLLER_BUTTON_DPAD_UP:    0x2,
+		sdl.CONTROLLER_BUTTON_DPAD_LEFT:  0x4,
+		sdl.CONTROLLER_BUTTON_DPAD_RIGHT: 0x6,
+		sdl.CONTROLLER_BUTTON_DPAD_DOWN:  0x8,
+		sdl.CONTROLLER_BUTTON_A:          0x5,
+		sdl.CONTROLLER_BUTTON_B:          0x0,
+		sdl.CONTROLLER_BUTTON_X:          0x1,
+		sdl.CONTROLLER_BUTTON_Y:          0x3,
+		sdl.CONTROLLER_BUTTON_BACK:       0xE,
+		sdl.CONTROLLER_BUTTON_START:      0xF,
+	}
+}
+
+/*
+Replaces the controller bindings. Every button must map to a keypad index from 0x0 to 0xF. The map is copied, and
+any keypad keys held down are released so none get stuck under the old bindings.
+*/
+func (c8 *chip8) SetButtonMap(buttonMap ButtonMap) error {
+	for button, key := range buttonMap {
+		if key > 0xF {
+			return fmt.Errorf("controller button %d is bound to keypad index 0x%X, expected 0x0-0xF", button, key)
+		}
+	}
+
+	c8.buttonMap = maps.Clone(buttonMap)
+	clear(c8.keypad[:])
+
+	return nil
+}
+
+// Creates the emulator with the given controller bindings in place of DefaultButtonMap, see SetButtonMap
+func WithButtonMap(buttonMap ButtonMap) Option {
+	return func(c8 *chip8) error {
+		return c8.SetButtonMap(buttonMap)
+	}
+}
+
+/*
+Opens a controller as it's plugged in. SDL reports controllers that were already connected at startup the same way.
+Devices SDL can't open as a game controller are ignored.
+*/
+func (c8 *chip8) openController(index int) {
+	controller := sdl.GameControllerOpen(index)
+	if controller == nil {
+		return
+	}
+
+	joystick := controller.Joystick()
+	if joystick == nil {
+		controller.Close()
+		return
+	}
+
+	c8.controllers[joystick.InstanceID()] = controller
+}
+
+// Closes a controller that was unplugged, releasing the keypad so nothing stays held down
+func (c8 *chip8) closeController(id sdl.JoystickID) {
+	controller, ok := c8.controllers[id]
+	if !ok {
+		return
+	}
+
+	controller.Close()
+	delete(c8.controllers, id)
+	clear(c8.keypad[:])
+}
+
+// Closes every open controller
+func (c8 *chip8) closeControllers() {
+	for id := range c8.controllers {
+		c8.closeController(id)
+	}
+}
+
+// Updates the keypad for a controller button going down or up, ignoring buttons that aren't bound
+func (c8 *chip8) pressButton(button sdl.GameControllerButton, pressed bool) {
+	key, ok := c8.buttonMap[button]
+	if !ok {
+		return
+	}
+
+	var s byte = 0
+	if pressed {
+		s = 1
+	}
+
+	if c8.inputLogEnabled && c8.keypad[key] != s {
+		c8.logInput(key, pressed)
+	}
+
+	c8.keypad[key] = s
+}
@@ -0,0 +1,60 @@
+package emulator
+
+import (
+	"testing"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+func TestPressButton(t *testing.T) {
+	tests := []struct {
+		name      string
+		buttonMap ButtonMap
+		button    sdl.GameControllerButton
+		pad       int
+		wantErr   bool
+	}{
+		{"D-pad up", DefaultButtonMap(), sdl.CONTROLLER_BUTTON_DPAD_UP, 0x2, false},
+		{"A", DefaultButtonMap(), sdl.CONTROLLER_BUTTON_A, 0x5, false},
+		{"start", DefaultButtonMap(), sdl.CONTROLLER_BUTTON_START, 0xF, false},
+		{"custom", ButtonMap{sdl.CONTROLLER_BUTTON_A: 0xC}, sdl.CONTROLLER_BUTTON_A, 0xC, false},
+		{"unbound", ButtonMap{sdl.CONTROLLER_BUTTON_A: 0xC}, sdl.CONTROLLER_BUTTON_B, -1, false},
+		{"index past the keypad", ButtonMap{sdl.CONTROLLER_BUTTON_A: 0x10}, sdl.CONTROLLER_BUTTON_A, 0x5, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c8 := newTestCore(t)
+
+			err := c8.SetButtonMap(tt.buttonMap)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SetButtonMap() error = %v, want error %t", err, tt.wantErr)
+			}
+
+			c8.pressButton(tt.button, true)
+			for k, v := range c8.keypad {
+				if pressed := v != 0; pressed != (k == tt.pad) {
+					t.Errorf("keypad 0x%X pressed = %t", k, pressed)
+				}
+			}
+
+			c8.pressButton(tt.button, false)
+			if tt.pad >= 0 && c8.keypad[tt.pad] != 0 {
+				t.Errorf("keypad 0x%X still pressed after releasing", tt.pad)
+			}
+		})
+	}
+}
+
+func TestSetButtonMapReleasesKeys(t *testing.T) {
+	c8 := newTestCore(t)
+	c8.pressButton(sdl.CONTROLLER_BUTTON_DPAD_UP, true)
+
+	if err := c8.SetButtonMap(ButtonMap{sdl.CONTROLLER_BUTTON_DPAD_UP: 0x8}); err != nil {
+		t.Fatal(err)
+	}
+
+	if c8.keypad[0x2] != 0 {
+		t.Error("key held under the old bindings still pressed")
+	}
+}
@@ -0,0 +1,32 @@
+package emulator
+
+import "time"
+
+// Maximum number of key transitions kept in the input log; the oldest are dropped first
+const INPUT_LOG_SIZE = 1024
+
+// A single keypad press or release and when it happened
+type InputEvent struct {
+	Key     byte
+	Pressed bool
+	Time    time.Time
+}
+
+// Starts or stops recording keypad press/release timestamps; disabling also discards what was recorded
+func (c8 *chip8) EnableInputLog(enabled bool) {
+	c8.inputLogEnabled = enabled
+	c8.inputLog = nil
+}
+
+// Returns a copy of the recorded key transitions, oldest first
+func (c8 *chip8) InputLog() []InputEvent {
+	return append([]InputEvent{}, c8.inputLog...)
+}
+
+func (c8 *chip8) logInput(key byte, pressed bool) {
+	if len(c8.inputLog) == INPUT_LOG_SIZE {
+		c8.inputLog = c8.inputLog[1:]
+	}
+
+	c8.inputLog = append(c8.inputLog, InputEvent{Key: key, Pressed: pressed, Time: c8.clock.Now()})
+}
@@ -0,0 +1,63 @@
+package emulator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+func TestInputLog(t *testing.T) {
+	c8, clk := newClockedCore(t)
+	c8.EnableInputLog(true)
+	start := clk.Now()
+
+	c8.handleKey(keyEvent(sdl.K_w, true))
+	clk.Advance(50 * time.Millisecond)
+	// Key repeat: still held, so nothing new to record
+	c8.handleKey(keyEvent(sdl.K_w, true))
+	clk.Advance(50 * time.Millisecond)
+	c8.handleKey(keyEvent(sdl.K_w, false))
+	// Not bound to the keypad
+	c8.handleKey(keyEvent(sdl.K_p, false))
+
+	want := []InputEvent{
+		{Key: 0x5, Pressed: true, Time: start},
+		{Key: 0x5, Pressed: false, Time: start.Add(100 * time.Millisecond)},
+	}
+
+	got := c8.InputLog()
+	if len(got) != len(want) {
+		t.Fatalf("logged %v, want %v", got, want)
+	}
+	for k := range want {
+		if got[k].Key != want[k].Key || got[k].Pressed != want[k].Pressed || !got[k].Time.Equal(want[k].Time) {
+			t.Errorf("event %d = %+v, want %+v", k, got[k], want[k])
+		}
+	}
+
+	c8.EnableInputLog(false)
+	c8.handleKey(keyEvent(sdl.K_w, true))
+	if len(c8.InputLog()) != 0 {
+		t.Error("disabling the log didn't discard it, or kept recording")
+	}
+}
+
+func TestInputLogSize(t *testing.T) {
+	c8 := newTestCore(t)
+	c8.EnableInputLog(true)
+
+	for k := range INPUT_LOG_SIZE + 10 {
+		c8.handleKey(keyEvent(sdl.K_x, k%2 == 0))
+	}
+
+	log := c8.InputLog()
+	if len(log) != INPUT_LOG_SIZE {
+		t.Fatalf("log holds %d events, want %d", len(log), INPUT_LOG_SIZE)
+	}
+
+	// The first ten, starting with a press, were dropped
+	if !log[0].Pressed || log[len(log)-1].Pressed {
+		t.Errorf("log runs from %+v to %+v, want a press to a release", log[0], log[len(log)-1])
+	}
+}
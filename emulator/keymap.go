@@ -0,0 +1,90 @@
+package emulator
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// Maps keyboard keys to CHIP-8 keypad indices (0x0-0xF)
+type KeyMap map[sdl.Keycode]byte
+
+// A single keypad key and the human-readable name of the keyboard key bound to it
+type KeyBinding struct {
+	Key  byte
+	Name string
+}
+
+// The QWERTY layout documented on the keypad field of the emulator
+func DefaultKeyMap() KeyMap {
+	return KeyMap{
+		sdl.K_x: 0x0,
+		sdl.K_1: 0x1,
+		sdl.K_2: 0x2,
+		sdl.K_3: 0x3,
+		sdl.K_q: 0x4,
+		sdl.K_w: 0x5,
+		sdl.K_e: 0x6,
+		sdl.K_a: 0x7,
+		sdl.K_s: 0x8,
+		sdl.K_d: 0x9,
+		sdl.K_z: 0xA,
+		sdl.K_c: 0xB,
+		sdl.K_4: 0xC,
+		sdl.K_r: 0xD,
+		sdl.K_f: 0xE,
+		sdl.K_v: 0xF,
+	}
+}
+
+/*
+Replaces the key bindings, for non-QWERTY layouts or custom controls. Every key must map to a keypad index from 0x0
+to 0xF. The map is copied, and any keypad keys held down are released so none get stuck under the old bindings.
+*/
+func (c8 *chip8) SetKeyMap(keyMap KeyMap) error {
+	for code, key := range keyMap {
+		if key > 0xF {
+			return fmt.Errorf("key %s is bound to keypad index 0x%X, expected 0x0-0xF", sdl.GetKeyName(code), key)
+		}
+	}
+
+	c8.keyMap = maps.Clone(keyMap)
+	clear(c8.keypad[:])
+
+	return nil
+}
+
+// Creates the emulator with the given key bindings in place of DefaultKeyMap, see SetKeyMap
+func WithKeyMap(keyMap KeyMap) Option {
+	return func(c8 *chip8) error {
+		return c8.SetKeyMap(keyMap)
+	}
+}
+
+// Returns the current key bindings ordered by keypad index, for showing the controls to the user
+func (c8 *chip8) KeyBindings() []KeyBinding {
+	bindings := []KeyBinding{}
+	for code, key := range c8.keyMap {
+		bindings = append(bindings, KeyBinding{Key: key, Name: sdl.GetKeyName(code)})
+	}
+
+	slices.SortFunc(bindings, func(a, b KeyBinding) int {
+		return cmp.Or(cmp.Compare(a.Key, b.Key), cmp.Compare(a.Name, b.Name))
+	})
+
+	return bindings
+}
+
+// Returns the name of the keyboard key bound to a keypad index, for prompts like "press 5"
+func (c8 *chip8) KeyForPad(index byte) (string, bool) {
+	for _, binding := range c8.KeyBindings() {
+		if binding.Key == index {
+			return binding.Name, true
+		}
+	}
+
+	return "", false
+}
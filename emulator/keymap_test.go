@@ -0,0 +1,233 @@
+package emulator
+
+import (
+	"testing"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+func TestKeyBindings(t *testing.T) {
+	tests := []struct {
+		name   string
+		keyMap KeyMap
+		want   []byte
+	}{
+		{"default", DefaultKeyMap(), []byte{0x0, 0x1, 0x2, 0x3, 0x4, 0x5, 0x6, 0x7, 0x8, 0x9, 0xA, 0xB, 0xC, 0xD, 0xE, 0xF}},
+		{"partial", KeyMap{sdl.K_UP: 0x2, sdl.K_DOWN: 0x8, sdl.K_SPACE: 0x5}, []byte{0x2, 0x5, 0x8}},
+		{"two keys on one pad key", KeyMap{sdl.K_UP: 0x2, sdl.K_w: 0x2, sdl.K_a: 0x1}, []byte{0x1, 0x2, 0x2}},
+		{"empty", KeyMap{}, []byte{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c8 := newTestCore(t)
+			if err := c8.SetKeyMap(tt.keyMap); err != nil {
+				t.Fatal(err)
+			}
+
+			bindings := c8.KeyBindings()
+			if len(bindings) != len(tt.want) {
+				t.Fatalf("%d bindings, want %d", len(bindings), len(tt.want))
+			}
+
+			for k, binding := range bindings {
+				if binding.Key != tt.want[k] {
+					t.Errorf("binding %d is for keypad 0x%X, want 0x%X", k, binding.Key, tt.want[k])
+				}
+			}
+		})
+	}
+}
+
+// Builds the event SDL delivers for a key going down or up
+func keyEvent(code sdl.Keycode, down bool) *sdl.KeyboardEvent {
+	event := &sdl.KeyboardEvent{Type: sdl.KEYUP, Keysym: sdl.Keysym{Sym: code}}
+	if down {
+		event.Type = sdl.KEYDOWN
+	}
+
+	return event
+}
+
+func TestEscapeQuits(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled bool
+		down    bool
+		quit    bool
+	}{
+		{"press", true, true, true},
+		{"release", true, false, false},
+		{"press when disabled", false, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c8 := newTestCore(t)
+			c8.SetEscapeQuits(tt.enabled)
+
+			if quit := c8.handleKey(keyEvent(sdl.K_ESCAPE, tt.down)); quit != tt.quit {
+				t.Errorf("quit = %v, want %v", quit, tt.quit)
+			}
+		})
+	}
+
+	// Escape can still be bound to the keypad once it no longer quits
+	c8 := newTestCore(t)
+	c8.SetEscapeQuits(false)
+	if err := c8.SetKeyMap(KeyMap{sdl.K_ESCAPE: 0xF}); err != nil {
+		t.Fatal(err)
+	}
+	c8.handleKey(keyEvent(sdl.K_ESCAPE, true))
+	if c8.keypad[0xF] != 1 {
+		t.Error("Escape didn't press the keypad key bound to it")
+	}
+}
+
+func TestKeyForPad(t *testing.T) {
+	tests := []struct {
+		name   string
+		keyMap KeyMap
+		index  byte
+		code   sdl.Keycode
+		found  bool
+	}{
+		{"default 0", DefaultKeyMap(), 0x0, sdl.K_x, true},
+		{"default 5", DefaultKeyMap(), 0x5, sdl.K_w, true},
+		{"default C", DefaultKeyMap(), 0xC, sdl.K_4, true},
+		{"default F", DefaultKeyMap(), 0xF, sdl.K_v, true},
+		{"custom", KeyMap{sdl.K_UP: 0x2, sdl.K_DOWN: 0x8}, 0x8, sdl.K_DOWN, true},
+		{"unbound", KeyMap{sdl.K_UP: 0x2}, 0x5, 0, false},
+		{"past the keypad", DefaultKeyMap(), 0x10, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c8 := newTestCore(t)
+			if err := c8.SetKeyMap(tt.keyMap); err != nil {
+				t.Fatal(err)
+			}
+
+			name, found := c8.KeyForPad(tt.index)
+			if found != tt.found {
+				t.Fatalf("KeyForPad(0x%X) found = %t, want %t", tt.index, found, tt.found)
+			}
+
+			if want := sdl.GetKeyName(tt.code); found && name != want {
+				t.Errorf("KeyForPad(0x%X) = %q, want %q", tt.index, name, want)
+			}
+		})
+	}
+}
+
+func TestPauseKey(t *testing.T) {
+	tests := []struct {
+		name   string
+		events []*sdl.KeyboardEvent
+		paused bool
+	}{
+		{"press", []*sdl.KeyboardEvent{keyEvent(sdl.K_p, true)}, true},
+		{"press and release", []*sdl.KeyboardEvent{keyEvent(sdl.K_p, true), keyEvent(sdl.K_p, false)}, true},
+		{"press twice", []*sdl.KeyboardEvent{keyEvent(sdl.K_p, true), keyEvent(sdl.K_p, false), keyEvent(sdl.K_p, true)}, false},
+		{"held down", []*sdl.KeyboardEvent{keyEvent(sdl.K_p, true), {Type: sdl.KEYDOWN, Repeat: 1, Keysym: sdl.Keysym{Sym: sdl.K_p}}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c8 := newTestCore(t)
+			for _, event := range tt.events {
+				c8.handleKey(event)
+			}
+
+			if c8.Paused() != tt.paused {
+				t.Errorf("paused = %t, want %t", c8.Paused(), tt.paused)
+			}
+		})
+	}
+}
+
+func TestSetKeyMap(t *testing.T) {
+	tests := []struct {
+		name    string
+		keyMap  KeyMap
+		key     sdl.Keycode
+		pad     int
+		wantErr bool
+	}{
+		{"default", DefaultKeyMap(), sdl.K_w, 0x5, false},
+		{"custom", KeyMap{sdl.K_UP: 0x2, sdl.K_DOWN: 0x8}, sdl.K_UP, 0x2, false},
+		{"old binding dropped", KeyMap{sdl.K_UP: 0x5}, sdl.K_w, -1, false},
+		{"index past the keypad", KeyMap{sdl.K_UP: 0x2, sdl.K_DOWN: 0x10}, sdl.K_w, 0x5, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c8 := newTestCore(t)
+			c8.keypad[0xA] = 1
+
+			err := c8.SetKeyMap(tt.keyMap)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SetKeyMap() error = %v, want error %t", err, tt.wantErr)
+			}
+
+			// A rejected map leaves the bindings and keys as they were; an accepted one releases held keys
+			if held := c8.keypad[0xA] != 0; held != tt.wantErr {
+				t.Errorf("key A held = %t after SetKeyMap", held)
+			}
+			c8.keypad[0xA] = 0
+
+			c8.handleKey(keyEvent(tt.key, true))
+			for k, v := range c8.keypad {
+				if pressed := v != 0; pressed != (k == tt.pad) {
+					t.Errorf("keypad 0x%X pressed = %t", k, pressed)
+				}
+			}
+
+			c8.handleKey(keyEvent(tt.key, false))
+			if tt.pad >= 0 && c8.keypad[tt.pad] != 0 {
+				t.Errorf("keypad 0x%X still pressed after releasing", tt.pad)
+			}
+		})
+	}
+}
+
+func TestSetKeyMapIsCopied(t *testing.T) {
+	keyMap := KeyMap{sdl.K_UP: 0x2}
+	c8 := newTestCore(t)
+	if err := c8.SetKeyMap(keyMap); err != nil {
+		t.Fatal(err)
+	}
+
+	keyMap[sdl.K_UP] = 0x3
+	c8.handleKey(keyEvent(sdl.K_UP, true))
+
+	if c8.keypad[0x2] == 0 || c8.keypad[0x3] != 0 {
+		t.Error("changing the map after SetKeyMap changed the bindings")
+	}
+}
+
+func TestFullscreenKey(t *testing.T) {
+	tests := []struct {
+		name       string
+		events     []*sdl.KeyboardEvent
+		fullscreen bool
+	}{
+		{"press", []*sdl.KeyboardEvent{keyEvent(sdl.K_F11, true)}, true},
+		{"release only", []*sdl.KeyboardEvent{keyEvent(sdl.K_F11, false)}, false},
+		{"press twice", []*sdl.KeyboardEvent{keyEvent(sdl.K_F11, true), keyEvent(sdl.K_F11, false), keyEvent(sdl.K_F11, true)}, false},
+		{"held down", []*sdl.KeyboardEvent{keyEvent(sdl.K_F11, true), {Type: sdl.KEYDOWN, Repeat: 1, Keysym: sdl.Keysym{Sym: sdl.K_F11}}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c8 := newTestCore(t)
+			for _, event := range tt.events {
+				c8.handleKey(event)
+			}
+
+			if c8.Fullscreen() != tt.fullscreen {
+				t.Errorf("fullscreen = %t, want %t", c8.Fullscreen(), tt.fullscreen)
+			}
+		})
+	}
+}
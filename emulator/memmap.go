@@ -0,0 +1,57 @@
+package emulator
+
+// What a stretch of memory is used for
+type MemRegionKind string
+
+const (
+	RegionInterpreter MemRegionKind = "interpreter"
+	RegionFontset     MemRegionKind = "fontset"
+	RegionCode        MemRegionKind = "code"
+	RegionData        MemRegionKind = "data"
+	RegionFree        MemRegionKind = "free"
+)
+
+// An inclusive range of addresses sharing the same classification
+type MemRegion struct {
+	Kind  MemRegionKind
+	Start uint16
+	End   uint16
+}
+
+/*
+Classifies memory into regions for understanding a ROM's layout. Bytes of the ROM that have been executed are code,
+the rest of the ROM is likely data. Coverage comes from the current run, so run the ROM for a while first.
+*/
+func (c8 *chip8) MemoryMap() []MemRegion {
+	regions := []MemRegion{}
+
+	add := func(kind MemRegionKind, addr int) {
+		last := len(regions) - 1
+		if last >= 0 && regions[last].Kind == kind && int(regions[last].End) == addr-1 {
+			regions[last].End = uint16(addr)
+			return
+		}
+
+		regions = append(regions, MemRegion{Kind: kind, Start: uint16(addr), End: uint16(addr)})
+	}
+
+	fontsetEnd := int(BIG_FONTSET_START_ADDRESS) + 160
+	romEnd := int(START_ADDRESS) + c8.romSize
+
+	for addr := range len(c8.memory) {
+		switch {
+		case addr >= int(FONTSET_START_ADDRESS) && addr < fontsetEnd:
+			add(RegionFontset, addr)
+		case addr < int(START_ADDRESS):
+			add(RegionInterpreter, addr)
+		case addr < romEnd && c8.executed[addr]:
+			add(RegionCode, addr)
+		case addr < romEnd:
+			add(RegionData, addr)
+		default:
+			add(RegionFree, addr)
+		}
+	}
+
+	return regions
+}
@@ -0,0 +1,52 @@
+package emulator
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestMemoryMap(t *testing.T) {
+	tests := []struct {
+		name     string
+		extended bool
+		steps    int
+		want     []MemRegion
+	}{
+		{"before running", false, 0, []MemRegion{
+			{RegionInterpreter, 0x000, 0x04F},
+			{RegionFontset, 0x050, 0x13F},
+			{RegionInterpreter, 0x140, 0x1FF},
+			{RegionData, 0x200, 0x205},
+			{RegionFree, 0x206, 0xFFF},
+		}},
+		{"after running", false, 3, []MemRegion{
+			{RegionInterpreter, 0x000, 0x04F},
+			{RegionFontset, 0x050, 0x13F},
+			{RegionInterpreter, 0x140, 0x1FF},
+			{RegionCode, 0x200, 0x203},
+			{RegionData, 0x204, 0x205},
+			{RegionFree, 0x206, 0xFFF},
+		}},
+		{"extended memory", true, 3, []MemRegion{
+			{RegionInterpreter, 0x000, 0x04F},
+			{RegionFontset, 0x050, 0x13F},
+			{RegionInterpreter, 0x140, 0x1FF},
+			{RegionCode, 0x200, 0x203},
+			{RegionData, 0x204, 0x205},
+			{RegionFree, 0x206, 0xFFFF},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// LD V0, 5; JP 0x202; then two bytes of data that never run
+			c8 := newTestCore(t, 0x60, 0x05, 0x12, 0x02, 0xAB, 0xCD)
+			c8.SetExtendedMemory(tt.extended)
+			stepN(t, c8, tt.steps)
+
+			if got := c8.MemoryMap(); !slices.Equal(got, tt.want) {
+				t.Errorf("memory map = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
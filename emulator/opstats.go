@@ -0,0 +1,138 @@
+package emulator
+
+import (
+	"encoding/json"
+	"io"
+	"maps"
+)
+
+// Returns the instruction-set pattern an opcode belongs to, e.g. 0x8124 is "8xy4", or "" if it isn't a known instruction
+func opcodePattern(opcode uint16) string {
+	switch opcode & 0xF000 {
+	case 0x0000:
+		switch opcode {
+		case 0x00E0:
+			return "00E0"
+		case 0x00EE:
+			return "00EE"
+		case 0x00FB:
+			return "00FB"
+		case 0x00FC:
+			return "00FC"
+		case 0x00FE:
+			return "00FE"
+		case 0x00FF:
+			return "00FF"
+		}
+
+		if opcode&0xFFF0 == 0x00C0 {
+			return "00Cn"
+		}
+	case 0x1000:
+		return "1nnn"
+	case 0x2000:
+		return "2nnn"
+	case 0x3000:
+		return "3xkk"
+	case 0x4000:
+		return "4xkk"
+	case 0x5000:
+		return "5xy0"
+	case 0x6000:
+		return "6xkk"
+	case 0x7000:
+		return "7xkk"
+	case 0x8000:
+		switch opcode & 0x000F {
+		case 0x0000:
+			return "8xy0"
+		case 0x0001:
+			return "8xy1"
+		case 0x0002:
+			return "8xy2"
+		case 0x0003:
+			return "8xy3"
+		case 0x0004:
+			return "8xy4"
+		case 0x0005:
+			return "8xy5"
+		case 0x0006:
+			return "8xy6"
+		case 0x0007:
+			return "8xy7"
+		case 0x000E:
+			return "8xyE"
+		}
+	case 0x9000:
+		return "9xy0"
+	case 0xA000:
+		return "Annn"
+	case 0xB000:
+		return "Bnnn"
+	case 0xC000:
+		return "Cxkk"
+	case 0xD000:
+		return "Dxyn"
+	case 0xE000:
+		switch opcode & 0x00FF {
+		case 0x009E:
+			return "Ex9E"
+		case 0x00A1:
+			return "ExA1"
+		}
+	case 0xF000:
+		if opcode == 0xF000 {
+			return "F000"
+		}
+
+		switch opcode & 0x00FF {
+		case 0x0001:
+			return "Fn01"
+		case 0x0007:
+			return "Fx07"
+		case 0x000A:
+			return "Fx0A"
+		case 0x0015:
+			return "Fx15"
+		case 0x0018:
+			return "Fx18"
+		case 0x001E:
+			return "Fx1E"
+		case 0x0029:
+			return "Fx29"
+		case 0x0030:
+			return "Fx30"
+		case 0x0033:
+			return "Fx33"
+		case 0x0055:
+			return "Fx55"
+		case 0x0065:
+			return "Fx65"
+		}
+	}
+
+	return ""
+}
+
+// Counts an executed opcode under its instruction pattern, with anything unrecognised counted under "unknown"
+func (c8 *chip8) countOpcode() {
+	pattern := opcodePattern(c8.opcode)
+	if pattern == "" {
+		pattern = "unknown"
+	}
+
+	c8.opcodeCounts[pattern]++
+}
+
+// Returns how many times each instruction pattern (e.g. "Dxyn") has been executed
+func (c8 *chip8) OpcodeCounts() map[string]uint64 {
+	return maps.Clone(c8.opcodeCounts)
+}
+
+// Writes the per-instruction execution counts to w as a JSON object keyed by instruction pattern
+func (c8 *chip8) WriteOpcodeStats(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(c8.opcodeCounts)
+}
@@ -0,0 +1,65 @@
+package emulator
+
+import (
+	"bytes"
+	"encoding/json"
+	"maps"
+	"testing"
+)
+
+func TestOpcodePattern(t *testing.T) {
+	tests := []struct {
+		opcode uint16
+		want   string
+	}{
+		{0x00E0, "00E0"},
+		{0x00C4, "00Cn"},
+		{0x0123, ""},
+		{0x1ABC, "1nnn"},
+		{0x5120, "5xy0"},
+		{0x8124, "8xy4"},
+		{0x812E, "8xyE"},
+		{0x8128, ""},
+		{0xD015, "Dxyn"},
+		{0xE39E, "Ex9E"},
+		{0xE3A0, ""},
+		{0xF000, "F000"},
+		{0xF201, "Fn01"},
+		{0xF533, "Fx33"},
+		{0xF599, ""},
+	}
+
+	for _, tt := range tests {
+		if got := opcodePattern(tt.opcode); got != tt.want {
+			t.Errorf("opcodePattern(0x%04X) = %q, want %q", tt.opcode, got, tt.want)
+		}
+	}
+}
+
+func TestWriteOpcodeStats(t *testing.T) {
+	// 7001 1200: count up in V0 forever
+	c8 := newTestCore(t, 0x70, 0x01, 0x12, 0x00)
+	stepN(t, c8, 7)
+
+	var buf bytes.Buffer
+	err := c8.WriteOpcodeStats(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[string]uint64{}
+	err = json.Unmarshal(buf.Bytes(), &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := map[string]uint64{"7xkk": 4, "1nnn": 3}; !maps.Equal(got, want) {
+		t.Errorf("stats = %v, want %v", got, want)
+	}
+
+	// The returned counts are a copy
+	c8.OpcodeCounts()["7xkk"] = 100
+	if c8.OpcodeCounts()["7xkk"] != 4 {
+		t.Error("changing the returned counts changed the machine's")
+	}
+}
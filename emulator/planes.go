@@ -0,0 +1,75 @@
+package emulator
+
+// One bitplane of the display, sized for high-res mode; a pixel is either 0x00000000 or 0xFFFFFFFF
+type displayPlane [HIRES_VIDEO_WIDTH * HIRES_VIDEO_HEIGHT]uint32
+
+// Number of XO-CHIP bitplanes, and the plane selected at power-on (just the first, which is the classic display)
+const PLANE_COUNT = 2
+const DEFAULT_PLANE_MASK = 0x1
+
+/*
+Colors for each combination of lit planes, indexed by bitmask: nothing lit, only the first plane, only the second
+plane, and both. ROMs that never select the second plane only ever show the first two.
+*/
+var DEFAULT_PLANE_COLORS = [1 << PLANE_COUNT]uint32{0x00000000, 0xFFFFFFFF, 0xFFFF6600, 0xFF662200}
+
+// Sets the colors shown for each combination of lit planes, indexed the same way as DEFAULT_PLANE_COLORS
+func (c8 *chip8) SetPlaneColors(colors [1 << PLANE_COUNT]uint32) {
+	c8.stateLock.Lock()
+	defer c8.stateLock.Unlock()
+
+	c8.planeColors = colors
+}
+
+// Returns the planes selected by the last FN01, in plane order
+func (c8 *chip8) selectedPlanes() []*displayPlane {
+	planes := []*displayPlane{}
+
+	for _, p := range c8.selectedPlaneIndices() {
+		planes = append(planes, c8.plane(p))
+	}
+
+	return planes
+}
+
+// Returns the numbers of the planes selected by the last FN01, in plane order
+func (c8 *chip8) selectedPlaneIndices() []int {
+	planes := []int{}
+
+	for p := range PLANE_COUNT {
+		if c8.planeMask&(1<<p) != 0 {
+			planes = append(planes, p)
+		}
+	}
+
+	return planes
+}
+
+// Returns bitplane p, where plane 0 is the classic display
+func (c8 *chip8) plane(p int) *displayPlane {
+	if p == 0 {
+		return &c8.pixels
+	}
+
+	return &c8.plane2
+}
+
+// Returns the color pixel k is rendered in, from which planes have it lit
+func (c8 *chip8) pixelColor(k int) uint32 {
+	combination := 0
+
+	for p := range PLANE_COUNT {
+		if c8.plane(p)[k] != 0 {
+			combination |= 1 << p
+		}
+	}
+
+	return c8.planeColors[combination]
+}
+
+// Clears every plane regardless of which are selected
+func (c8 *chip8) clearAllPlanes() {
+	for p := range PLANE_COUNT {
+		clear(c8.plane(p)[:])
+	}
+}
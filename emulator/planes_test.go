@@ -0,0 +1,197 @@
+package emulator
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// A display buffer in the default plane colors with pixel (x, 0) showing combination x for the first four columns
+func planeBuffer(width, height int) [][]uint32 {
+	buf := make([][]uint32, height)
+	for y := range buf {
+		buf[y] = make([]uint32, width)
+		for x := range buf[y] {
+			buf[y][x] = DEFAULT_PLANE_COLORS[0]
+		}
+	}
+	copy(buf[0], DEFAULT_PLANE_COLORS[:])
+
+	return buf
+}
+
+func TestSetDisplayBufferPlanes(t *testing.T) {
+	c8 := newTestCore(t)
+
+	if err := c8.SetDisplayBuffer(planeBuffer(VIDEO_WIDTH, VIDEO_HEIGHT)); err != nil {
+		t.Fatal(err)
+	}
+
+	for combination, color := range DEFAULT_PLANE_COLORS {
+		if got := c8.GetPixel(combination, 0); got != color {
+			t.Errorf("GetPixel(%d, 0) = 0x%08X, want 0x%08X", combination, got, color)
+		}
+
+		for p := range PLANE_COUNT {
+			lit := c8.plane(p)[combination] != 0
+			if want := combination&(1<<p) != 0; lit != want {
+				t.Errorf("pixel %d lit in plane %d = %v, want %v", combination, p, lit, want)
+			}
+		}
+	}
+}
+
+func TestSetDisplayBufferErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(buf [][]uint32) [][]uint32
+	}{
+		{"too few rows", func(buf [][]uint32) [][]uint32 { return buf[1:] }},
+		{"short row", func(buf [][]uint32) [][]uint32 { buf[3] = buf[3][1:]; return buf }},
+		{"unknown color", func(buf [][]uint32) [][]uint32 { buf[VIDEO_HEIGHT-1][VIDEO_WIDTH-1] = 0xFF123456; return buf }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c8 := newTestCore(t)
+			c8.plane(0)[5] = 0xFFFFFFFF
+
+			if err := c8.SetDisplayBuffer(tt.modify(planeBuffer(VIDEO_WIDTH, VIDEO_HEIGHT))); err == nil {
+				t.Fatal("bad buffer was accepted")
+			}
+
+			if c8.GetPixel(0, 0) != DEFAULT_PLANE_COLORS[0] || c8.GetPixel(5, 0) != DEFAULT_PLANE_COLORS[1] {
+				t.Error("a rejected buffer changed the display")
+			}
+		})
+	}
+}
+
+func TestFillDisplayPatternPlanes(t *testing.T) {
+	tests := []struct {
+		name string
+		mask byte
+	}{
+		{"first plane", 0x1},
+		{"second plane", 0x2},
+		{"both planes", 0x3},
+		{"no planes", 0x0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c8 := newTestCore(t)
+			c8.planeMask = tt.mask
+			c8.FillDisplayPattern(func(x, y int) bool { return x == y })
+
+			for p := range PLANE_COUNT {
+				want := tt.mask&(1<<p) != 0
+				if lit := c8.plane(p)[VIDEO_WIDTH+1] != 0; lit != want {
+					t.Errorf("plane %d lit on the diagonal = %v, want %v", p, lit, want)
+				}
+				if c8.plane(p)[1] != 0 {
+					t.Errorf("plane %d lit off the diagonal", p)
+				}
+			}
+
+			if want := DEFAULT_PLANE_COLORS[tt.mask]; c8.GetPixel(2, 2) != want {
+				t.Errorf("GetPixel(2, 2) = 0x%08X, want 0x%08X", c8.GetPixel(2, 2), want)
+			}
+		})
+	}
+}
+
+func TestPlaneDrawModes(t *testing.T) {
+	tests := []struct {
+		name      string
+		modes     [PLANE_COUNT]DrawMode
+		lit       [PLANE_COUNT]bool
+		collision byte
+	}{
+		{"both XOR", [PLANE_COUNT]DrawMode{DrawXOR, DrawXOR}, [PLANE_COUNT]bool{false, false}, 1},
+		{"both OR", [PLANE_COUNT]DrawMode{DrawOR, DrawOR}, [PLANE_COUNT]bool{true, true}, 0},
+		{"first XOR, second OR", [PLANE_COUNT]DrawMode{DrawXOR, DrawOR}, [PLANE_COUNT]bool{false, true}, 1},
+		{"first OR, second XOR", [PLANE_COUNT]DrawMode{DrawOR, DrawXOR}, [PLANE_COUNT]bool{true, false}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// F301 selects both planes, then the same one-row sprite for each plane is drawn twice at (0, 0)
+			c8 := newTestCore(t, 0xF3, 0x01, 0xA2, 0x0A, 0xD0, 0x01, 0xD0, 0x01, 0x12, 0x08, 0x80, 0x80)
+			for p, mode := range tt.modes {
+				if err := c8.SetPlaneDrawMode(p, mode); err != nil {
+					t.Fatal(err)
+				}
+			}
+			stepN(t, c8, 4)
+
+			for p := range PLANE_COUNT {
+				if lit := c8.plane(p)[0] != 0; lit != tt.lit[p] {
+					t.Errorf("plane %d lit after drawing twice = %v, want %v", p, lit, tt.lit[p])
+				}
+			}
+
+			if c8.registers[0xF] != tt.collision {
+				t.Errorf("VF = %d, want %d", c8.registers[0xF], tt.collision)
+			}
+		})
+	}
+}
+
+func TestSetPlaneDrawModeRange(t *testing.T) {
+	c8 := newTestCore(t)
+
+	for _, plane := range []int{-1, PLANE_COUNT} {
+		if err := c8.SetPlaneDrawMode(plane, DrawOR); err == nil {
+			t.Errorf("plane %d was accepted", plane)
+		}
+	}
+
+	c8.SetDrawMode(DrawOR)
+	if c8.drawModes != [PLANE_COUNT]DrawMode{DrawOR, DrawOR} {
+		t.Errorf("SetDrawMode left modes %v", c8.drawModes)
+	}
+}
+
+func TestSetDisplayBufferWhileRunning(t *testing.T) {
+	// HIGH; DRW V0, V0, 5; LOW; DRW V0, V0, 5 over and over, switching resolution and toggling the top-left corner
+	c8 := newTestCore(t, 0x00, 0xFF, 0xD0, 0x05, 0x00, 0xFE, 0xD0, 0x05, 0x12, 0x00)
+	buf := planeBuffer(VIDEO_WIDTH, VIDEO_HEIGHT)
+	screenshot := filepath.Join(t.TempDir(), "screenshot.png")
+	c8.videoScale = 1 // one pixel per display pixel keeps the screenshots quick
+
+	done := make(chan error)
+	go func() {
+		for range 20000 {
+			if err := c8.headlessCycle(); err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- nil
+	}()
+
+	for range 200 {
+		// A low-res buffer doesn't fit while the ROM is in high-res, which is reported rather than half written
+		if err := c8.SetDisplayBuffer(buf); err != nil && !strings.Contains(err.Error(), "expected 64") {
+			t.Fatal(err)
+		}
+		c8.GetPixel(0, 0)
+		c8.DisplayHash()
+		if err := c8.Screenshot(screenshot); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	// The ROM stopped in low-res, so a low-res buffer fits again
+	if err := c8.SetDisplayBuffer(buf); err != nil {
+		t.Fatal(err)
+	}
+	if got := c8.GetPixel(VIDEO_WIDTH-1, VIDEO_HEIGHT-1); got != DEFAULT_PLANE_COLORS[0] {
+		t.Errorf("far corner = 0x%08X, want 0x%08X", got, DEFAULT_PLANE_COLORS[0])
+	}
+}
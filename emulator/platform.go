@@ -0,0 +1,103 @@
+package emulator
+
+import (
+	"errors"
+	"fmt"
+	"log"
+)
+
+// A CHIP-8 variant, deciding which instructions a ROM written for it is expected to use
+type Platform string
+
+const (
+	// No platform selected, the default: every instruction the emulator knows runs without comment
+	PlatformNone Platform = ""
+
+	// The original COSMAC VIP instruction set
+	PlatformCHIP8 Platform = "chip8"
+
+	// SUPER-CHIP 1.1, adding high-res mode, scrolling, 16x16 sprites and the big font
+	PlatformSCHIP Platform = "schip"
+
+	// XO-CHIP, adding bitplanes and 16-bit addresses on top of SUPER-CHIP
+	PlatformXOCHIP Platform = "xochip"
+)
+
+// Each platform extends the instruction set of the ones before it
+var platformLevels = map[Platform]int{PlatformCHIP8: 1, PlatformSCHIP: 2, PlatformXOCHIP: 3}
+
+/*
+The load/store quirk each platform's preset selects (see SetIncrementIndexOnLoadStore): the COSMAC VIP's Fx55 and
+Fx65 leave I past the last register, while SUPER-CHIP and XO-CHIP ROMs are run with I left unchanged.
+*/
+var platformIncrementIndex = map[Platform]bool{PlatformCHIP8: true, PlatformSCHIP: false, PlatformXOCHIP: false}
+
+// Returned, wrapped with the name given, when ParsePlatform doesn't recognise a platform
+var ErrUnknownPlatform = errors.New("unknown platform")
+
+// Returns the platform with the given name, chip8, schip or xochip, or PlatformNone for an empty name
+func ParsePlatform(name string) (Platform, error) {
+	p := Platform(name)
+	if _, ok := platformLevels[p]; !ok && p != PlatformNone {
+		return PlatformNone, fmt.Errorf("%w %q, expected chip8, schip or xochip", ErrUnknownPlatform, name)
+	}
+
+	return p, nil
+}
+
+/*
+Selects the platform the ROM was written for. Each SUPER-CHIP or XO-CHIP instruction the ROM runs that the platform
+doesn't have is logged once, suggesting the platform that does, since a ROM running on the wrong platform tends to
+misbehave without any error. PlatformNone turns the warnings off.
+
+The platform's preset also sets the load/store quirk: chip8 increments I on Fx55/Fx65, schip and xochip leave it
+unchanged. PlatformNone keeps the quirk as it is, and SetIncrementIndexOnLoadStore can still override the preset.
+*/
+func (c8 *chip8) SetPlatform(p Platform) {
+	c8.platform = p
+	clear(c8.platformWarned)
+
+	if increment, ok := platformIncrementIndex[p]; ok {
+		c8.incrementIndex = increment
+	}
+}
+
+// Returns the selected platform, PlatformNone unless changed
+func (c8 *chip8) Platform() Platform {
+	return c8.platform
+}
+
+// Returns the instruction pattern of an opcode added by SUPER-CHIP or XO-CHIP, and the platform that added it
+func extensionOpcode(opcode uint16) (string, Platform, bool) {
+	switch {
+	case opcode == 0x00FB, opcode == 0x00FC, opcode == 0x00FE, opcode == 0x00FF:
+		return fmt.Sprintf("%04X", opcode), PlatformSCHIP, true
+	case opcode&0xFFF0 == 0x00C0:
+		return "00Cn", PlatformSCHIP, true
+	case opcode&0xF00F == 0xD000:
+		return "Dxy0", PlatformSCHIP, true
+	case opcode&0xF0FF == 0xF030:
+		return "Fx30", PlatformSCHIP, true
+	case opcode == 0xF000:
+		return "F000", PlatformXOCHIP, true
+	case opcode&0xF0FF == 0xF001:
+		return "Fn01", PlatformXOCHIP, true
+	}
+
+	return "", PlatformNone, false
+}
+
+// Logs, once per instruction pattern, when the fetched opcode at pc isn't part of the selected platform
+func (c8 *chip8) checkPlatform(pc uint16) {
+	if c8.platform == PlatformNone {
+		return
+	}
+
+	name, added, ok := extensionOpcode(c8.opcode)
+	if !ok || platformLevels[added] <= platformLevels[c8.platform] || c8.platformWarned[name] {
+		return
+	}
+	c8.platformWarned[name] = true
+
+	log.Printf("%s at 0x%04X is a %s instruction, which the %s platform doesn't have; try the %s platform", name, pc, added, c8.platform, added)
+}
@@ -0,0 +1,148 @@
+package emulator
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"testing"
+)
+
+// Collects what's logged for the rest of the test
+func captureLog(t *testing.T) *bytes.Buffer {
+	var buf bytes.Buffer
+	flags := log.Flags()
+	log.SetOutput(&buf)
+	log.SetFlags(0)
+	t.Cleanup(func() {
+		log.SetOutput(os.Stderr)
+		log.SetFlags(flags)
+	})
+
+	return &buf
+}
+
+func TestParsePlatform(t *testing.T) {
+	tests := []struct {
+		name string
+		want Platform
+		err  error
+	}{
+		{"chip8", PlatformCHIP8, nil},
+		{"schip", PlatformSCHIP, nil},
+		{"xochip", PlatformXOCHIP, nil},
+		{"", PlatformNone, nil},
+		{"superchip", PlatformNone, ErrUnknownPlatform},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := ParsePlatform(tt.name)
+			if p != tt.want || !errors.Is(err, tt.err) {
+				t.Errorf("ParsePlatform(%q) = %q, %v, want %q, %v", tt.name, p, err, tt.want, tt.err)
+			}
+		})
+	}
+}
+
+func TestPlatformWarning(t *testing.T) {
+	tests := []struct {
+		name     string
+		platform Platform
+		opcode   []byte
+		want     []string
+	}{
+		{"high-res under chip8", PlatformCHIP8, []byte{0x00, 0xFF}, []string{"00FF at 0x0200", "try the schip platform"}},
+		{"scroll under chip8", PlatformCHIP8, []byte{0x00, 0xC4}, []string{"00Cn at 0x0200", "try the schip platform"}},
+		{"16x16 sprite under chip8", PlatformCHIP8, []byte{0xD0, 0x10}, []string{"Dxy0 at 0x0200", "try the schip platform"}},
+		{"big font under chip8", PlatformCHIP8, []byte{0xF3, 0x30}, []string{"Fx30 at 0x0200", "try the schip platform"}},
+		{"plane select under schip", PlatformSCHIP, []byte{0xF2, 0x01}, []string{"Fn01 at 0x0200", "try the xochip platform"}},
+		{"high-res under schip", PlatformSCHIP, []byte{0x00, 0xFF}, nil},
+		{"plane select under xochip", PlatformXOCHIP, []byte{0xF2, 0x01}, nil},
+		{"high-res with no platform", PlatformNone, []byte{0x00, 0xFF}, nil},
+		{"CHIP-8 instruction under chip8", PlatformCHIP8, []byte{0x00, 0xE0}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The opcode, then JP back to it so it runs twice
+			c8 := newTestCore(t, tt.opcode[0], tt.opcode[1], 0x12, 0x00)
+			c8.SetPlatform(tt.platform)
+			logged := captureLog(t)
+			stepN(t, c8, 4)
+
+			lines := strings.Split(strings.TrimSpace(logged.String()), "\n")
+			if tt.want == nil {
+				if logged.Len() > 0 {
+					t.Errorf("logged %q, want nothing", logged)
+				}
+				return
+			}
+
+			// Each instruction is only warned about once
+			if len(lines) != 1 {
+				t.Fatalf("logged %d lines, want 1: %q", len(lines), logged)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(lines[0], want) {
+					t.Errorf("warning %q doesn't mention %q", lines[0], want)
+				}
+			}
+
+			// A new ROM is warned about again
+			logged.Reset()
+			if err := c8.Reset(true); err != nil {
+				t.Fatal(err)
+			}
+			stepN(t, c8, 1)
+			if logged.Len() == 0 {
+				t.Error("no warning after reloading the ROM")
+			}
+		})
+	}
+}
+
+func TestPlatformPresets(t *testing.T) {
+	tests := []struct {
+		name     string
+		platform Platform
+		initial  bool
+		want     bool
+		index    uint16
+	}{
+		{"chip8 increments I", PlatformCHIP8, false, true, 0x303},
+		{"schip leaves I", PlatformSCHIP, true, false, 0x300},
+		{"xochip leaves I", PlatformXOCHIP, true, false, 0x300},
+		{"no platform keeps the quirk off", PlatformNone, false, false, 0x300},
+		{"no platform keeps the quirk on", PlatformNone, true, true, 0x303},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// LD I, 0x300; LD [I], V2
+			c8 := newTestCore(t, 0xA3, 0x00, 0xF2, 0x55)
+			c8.SetIncrementIndexOnLoadStore(tt.initial)
+			c8.SetPlatform(tt.platform)
+
+			if c8.incrementIndex != tt.want {
+				t.Errorf("increment index quirk = %t, want %t", c8.incrementIndex, tt.want)
+			}
+
+			stepN(t, c8, 2)
+			if c8.indexRegister != tt.index {
+				t.Errorf("I = 0x%04X after Fx55, want 0x%04X", c8.indexRegister, tt.index)
+			}
+		})
+	}
+}
+
+func TestPlatformPresetOverridden(t *testing.T) {
+	c8 := newTestCore(t)
+	c8.SetPlatform(PlatformSCHIP)
+	c8.SetIncrementIndexOnLoadStore(true)
+
+	if !c8.incrementIndex {
+		t.Error("SetIncrementIndexOnLoadStore didn't override the schip preset")
+	}
+}
@@ -0,0 +1,96 @@
+package emulator
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+// A loop through every opcode a quirk changes: SHR, OR, Fx55/Fx65, Dxyn and Bnnn
+var quirkWorkload = []byte{
+	0xA3, 0x00, // LD I, 0x300
+	0x61, 0x05, // LD V1, 5
+	0x81, 0x26, // SHR V1, V2
+	0x83, 0x51, // OR V3, V5
+	0xF3, 0x55, // LD [I], V3
+	0xF3, 0x65, // LD V3, [I]
+	0xD4, 0x15, // DRW V4, V1, 5
+	0x74, 0x01, // ADD V4, 1
+	0xB2, 0x00, // JP V0, 0x200; V0 and V2 are never set, so Bxnn lands in the same place
+}
+
+var quirkConfigs = []struct {
+	name  string
+	apply func(c8 *chip8)
+}{
+	{"default", func(c8 *chip8) {}},
+	{"no index increment", func(c8 *chip8) { c8.SetIncrementIndexOnLoadStore(false) }},
+	{"shift uses Vy", func(c8 *chip8) { c8.SetShiftUsesVy(true) }},
+	{"Bxnn jump", func(c8 *chip8) { c8.SetBxnnJumpQuirk(true) }},
+	{"strict jumps", func(c8 *chip8) { c8.SetStrictJumps(true) }},
+	{"VF poison", func(c8 *chip8) { c8.SetVFPoison(true) }},
+	{"all", func(c8 *chip8) {
+		c8.SetIncrementIndexOnLoadStore(false)
+		c8.SetShiftUsesVy(true)
+		c8.SetBxnnJumpQuirk(true)
+		c8.SetStrictJumps(true)
+		c8.SetVFPoison(true)
+	}},
+}
+
+func BenchmarkQuirks(b *testing.B) {
+	for _, q := range quirkConfigs {
+		b.Run(q.name, func(b *testing.B) {
+			c8 := newTestCore(b, quirkWorkload...)
+			q.apply(c8)
+
+			instructions := 0
+			for b.Loop() {
+				if err := c8.headlessCycle(); err != nil {
+					b.Fatal(err)
+				}
+				instructions++
+			}
+
+			b.ReportMetric(float64(instructions)/b.Elapsed().Seconds(), "instructions/s")
+		})
+	}
+}
+
+// Runs the workload for a fixed number of instructions a few times and returns the best rate, which is the least noisy
+func quirkThroughput(t *testing.T, apply func(c8 *chip8)) float64 {
+	const instructions = 100000
+
+	rates := []float64{}
+	for range 3 {
+		c8 := newTestCore(t, quirkWorkload...)
+		apply(c8)
+
+		start := time.Now()
+		for range instructions {
+			if err := c8.headlessCycle(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		rates = append(rates, instructions/time.Since(start).Seconds())
+	}
+
+	return slices.Max(rates)
+}
+
+func TestQuirkThroughput(t *testing.T) {
+	if testing.Short() {
+		t.Skip("timing test")
+	}
+
+	baseline := quirkThroughput(t, quirkConfigs[0].apply)
+
+	for _, q := range quirkConfigs[1:] {
+		t.Run(q.name, func(t *testing.T) {
+			// Generous, so a busy machine doesn't fail it; per-instruction overhead from quirk checks would be far worse
+			if got := quirkThroughput(t, q.apply); got < baseline/3 {
+				t.Errorf("%.0f instructions/s, less than a third of the default's %.0f", got, baseline)
+			}
+		})
+	}
+}
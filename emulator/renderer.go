@@ -0,0 +1,54 @@
+package emulator
+
+import "github.com/veandco/go-sdl2/sdl"
+
+/*
+Draws frames for the main loop. Each frame is a Clear, a Draw for every rect, then a Present.
+Rects are in window coordinates and colors are 0xAARRGGBB.
+*/
+type Renderer interface {
+	Clear()
+	Draw(rect sdl.Rect, argb uint32)
+	Present()
+}
+
+// Renders to the SDL window surface
+type windowRenderer struct {
+	c8 *chip8
+}
+
+func (r windowRenderer) Clear() {
+	r.c8.fillRect(nil, 0)
+}
+
+func (r windowRenderer) Draw(rect sdl.Rect, argb uint32) {
+	r.c8.fillRect(&rect, argb)
+}
+
+func (r windowRenderer) Present() {
+	r.c8.window.UpdateSurface()
+}
+
+// Discards every frame, for running without a display
+type nullRenderer struct{}
+
+func (nullRenderer) Clear()                {}
+func (nullRenderer) Draw(sdl.Rect, uint32) {}
+func (nullRenderer) Present()              {}
+
+/*
+Skips SDL entirely: no window, audio or keyboard, and frames are drawn to a renderer that discards them.
+The pixel buffer is still kept up to date for GetPixel and DisplayHash. With no window to close, Run() only returns
+once SetMaxRunTime's limit is reached or the ROM halts with SetOnHalt(HaltExit).
+*/
+func Headless() Option {
+	return func(c8 *chip8) error {
+		c8.headless = true
+		return nil
+	}
+}
+
+// Replaces what frames are drawn to, for example to capture them in tests
+func (c8 *chip8) SetRenderer(renderer Renderer) {
+	c8.renderer = renderer
+}
@@ -0,0 +1,372 @@
+package emulator
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// Keeps the rects drawn for the last frame and counts the frames presented
+type captureRenderer struct {
+	frames int
+	rects  []sdl.Rect
+	colors []uint32
+}
+
+func (r *captureRenderer) Clear() {
+	r.rects = nil
+	r.colors = nil
+}
+
+func (r *captureRenderer) Draw(rect sdl.Rect, argb uint32) {
+	r.rects = append(r.rects, rect)
+	r.colors = append(r.colors, argb)
+}
+
+func (r *captureRenderer) Present() {
+	r.frames++
+}
+
+// Returns how many rects of the last frame were drawn in the given color
+func (r *captureRenderer) count(argb uint32) int {
+	n := 0
+	for _, c := range r.colors {
+		if c == argb {
+			n++
+		}
+	}
+
+	return n
+}
+
+func TestPersistence(t *testing.T) {
+	tests := []struct {
+		name   string
+		frames int
+		lit    int
+	}{
+		{"off", 0, 0},
+		{"one frame is off", 1, 0},
+		{"two frames", 2, 1},
+		{"five frames", 5, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// LD I, 0x050; DRW V0, V0, 5 twice, drawing the font's 0 at (0, 0) and XORing it off again
+			c8 := newTestCore(t, 0xA0, 0x50, 0xD0, 0x05, 0xD0, 0x05)
+			renderer := &captureRenderer{}
+			c8.SetRenderer(renderer)
+			c8.SetPersistence(tt.frames)
+			background := c8.planeColors[0]
+
+			stepN(t, c8, 2)
+			c8.update()
+			if renderer.colors[0] == background {
+				t.Fatal("pixel not rendered on after the draw")
+			}
+
+			stepN(t, c8, 1)
+			if c8.GetPixel(0, 0) != background {
+				t.Fatal("pixel still on in the display after XORing it off")
+			}
+
+			// Rendered on for the frames still owed after the first, then cleared
+			for frame := range tt.lit + 2 {
+				c8.update()
+				on := renderer.colors[0] != background
+				if on != (frame < tt.lit) {
+					t.Errorf("frame %d after XORing off rendered on = %t", frame+1, on)
+				}
+			}
+		})
+	}
+}
+
+func TestPersistenceColors(t *testing.T) {
+	const background, lit, both = 0xFF102030, 0xFF405060, 0xFF708090
+
+	tests := []struct {
+		name  string
+		plane int
+		color uint32
+	}{
+		{"first plane", 0, lit},
+		{"second plane", 1, 0xFFFFFF00},
+		{"both planes", -1, both},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c8 := newTestCore(t)
+			renderer := &captureRenderer{}
+			c8.SetRenderer(renderer)
+			c8.SetPlaneColors([1 << PLANE_COUNT]uint32{background, lit, 0xFFFFFF00, both})
+			c8.SetPersistence(3)
+
+			for p := range PLANE_COUNT {
+				if tt.plane < 0 || tt.plane == p {
+					c8.plane(p)[0] = 0xFFFFFFFF
+				}
+			}
+			c8.update()
+			c8.clearAllPlanes()
+
+			// Shown lit for 3 frames in all, then back to the background
+			want := []uint32{tt.color, tt.color, background, background}
+			for frame, color := range want {
+				c8.update()
+				if renderer.colors[0] != color {
+					t.Errorf("frame %d after turning off rendered 0x%08X, want 0x%08X", frame+1, renderer.colors[0], color)
+				}
+			}
+
+			if n := renderer.count(background); n != len(renderer.colors) {
+				t.Errorf("%d of %d pixels rendered in the background color", n, len(renderer.colors))
+			}
+		})
+	}
+}
+
+func TestScaledFramebufferPlanes(t *testing.T) {
+	c8 := newTestCore(t)
+	c8.SetPlaneColors([1 << PLANE_COUNT]uint32{0xFF000001, 0xFF000002, 0xFF000003, 0xFF000004})
+
+	c8.plane(0)[0] = 0xFFFFFFFF
+	c8.plane(1)[1] = 0xFFFFFFFF
+	c8.plane(0)[2] = 0xFFFFFFFF
+	c8.plane(1)[2] = 0xFFFFFFFF
+
+	buffer := c8.ScaledFramebuffer(2)
+	if len(buffer) != 2*VIDEO_HEIGHT || len(buffer[0]) != 2*VIDEO_WIDTH {
+		t.Fatalf("buffer is %dx%d, want %dx%d", len(buffer[0]), len(buffer), 2*VIDEO_WIDTH, 2*VIDEO_HEIGHT)
+	}
+
+	want := []uint32{0xFF000002, 0xFF000003, 0xFF000004, 0xFF000001}
+	for x, color := range want {
+		for _, point := range [][2]int{{2 * x, 0}, {2*x + 1, 1}} {
+			if got := buffer[point[1]][point[0]]; got != color {
+				t.Errorf("pixel (%d, %d) = 0x%08X, want 0x%08X", point[0], point[1], got, color)
+			}
+		}
+	}
+}
+
+func TestGridOverlay(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled bool
+		spacing int
+		lines   int
+		firstX  int32
+	}{
+		{"off", false, 8, 0, 0},
+		{"every 8 pixels", true, 8, 7 + 3, 80},
+		{"every 16 pixels", true, 16, 3 + 1, 160},
+		{"default spacing", true, 0, 7 + 3, 80},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c8 := newTestCore(t)
+			renderer := &captureRenderer{}
+			c8.SetRenderer(renderer)
+			c8.viewport = sdl.Rect{W: VIDEO_WIDTH * 10, H: VIDEO_HEIGHT * 10}
+			c8.SetGridOverlay(tt.enabled, tt.spacing)
+
+			c8.update()
+
+			if n := renderer.count(GRID_COLOR); n != tt.lines {
+				t.Fatalf("%d grid lines drawn, want %d", n, tt.lines)
+			}
+			if tt.lines == 0 {
+				return
+			}
+
+			// Lines come after the pixels, vertical ones first
+			first := renderer.rects[len(renderer.rects)-tt.lines]
+			if want := (sdl.Rect{X: tt.firstX, Y: 0, W: 1, H: VIDEO_HEIGHT * 10}); first != want {
+				t.Errorf("first line = %+v, want %+v", first, want)
+			}
+		})
+	}
+}
+
+func TestDebugDrawBounds(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled bool
+		hires   bool
+		sprite  byte
+		outline []sdl.Rect
+	}{
+		{"off", false, false, 0xD0, nil},
+		{"8 pixel sprite", true, false, 0x15, []sdl.Rect{{X: 30, Y: 20, W: 80, H: 1}, {X: 30, Y: 69, W: 80, H: 1}, {X: 30, Y: 20, W: 1, H: 50}, {X: 109, Y: 20, W: 1, H: 50}}},
+		{"16 pixel sprite", true, true, 0x10, []sdl.Rect{{X: 15, Y: 10, W: 80, H: 1}, {X: 15, Y: 89, W: 80, H: 1}, {X: 15, Y: 10, W: 1, H: 80}, {X: 94, Y: 10, W: 1, H: 80}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// LD V0, 3; LD V1, 2; DRW V0, V1, n with I left at 0
+			c8 := newTestCore(t, 0x60, 0x03, 0x61, 0x02, 0xD0, tt.sprite)
+			renderer := &captureRenderer{}
+			c8.SetRenderer(renderer)
+			c8.viewport = sdl.Rect{W: VIDEO_WIDTH * 10, H: VIDEO_HEIGHT * 10}
+			c8.SetDebugDrawBounds(tt.enabled)
+			c8.setHires(tt.hires)
+
+			// Nothing is outlined before the first draw
+			c8.update()
+			if n := renderer.count(DEBUG_DRAW_COLOR); n != 0 {
+				t.Fatalf("%d outline rects drawn before any sprite", n)
+			}
+
+			stepN(t, c8, 3)
+			c8.update()
+
+			outline := []sdl.Rect{}
+			for k, color := range renderer.colors {
+				if color == DEBUG_DRAW_COLOR {
+					outline = append(outline, renderer.rects[k])
+				}
+			}
+
+			if !slices.Equal(outline, tt.outline) && len(outline)+len(tt.outline) > 0 {
+				t.Errorf("outline = %v, want %v", outline, tt.outline)
+			}
+		})
+	}
+}
+
+func TestDrawnPixelsRenderLit(t *testing.T) {
+	tests := []struct {
+		name  string
+		draws int
+		value uint32
+		color uint32
+	}{
+		{"drawn", 1, 0xFFFFFFFF, DEFAULT_PLANE_COLORS[1]},
+		{"erased", 2, 0x00000000, DEFAULT_PLANE_COLORS[0]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// LD I, 0x050; DRW V0, V0, 5 twice, drawing the font's 0 at (0, 0) and erasing it
+			c8 := newTestCore(t, 0xA0, 0x50, 0xD0, 0x05, 0xD0, 0x05)
+			renderer := &captureRenderer{}
+			c8.SetRenderer(renderer)
+			stepN(t, c8, 1+tt.draws)
+
+			if c8.pixels[0] != tt.value {
+				t.Errorf("pixel holds 0x%08X, want 0x%08X", c8.pixels[0], tt.value)
+			}
+
+			c8.update()
+			if renderer.colors[0] != tt.color {
+				t.Errorf("pixel rendered 0x%08X, want 0x%08X", renderer.colors[0], tt.color)
+			}
+		})
+	}
+}
+
+func TestHeadlessRun(t *testing.T) {
+	tests := []struct {
+		name   string
+		frames int
+	}{
+		{"one frame", 1},
+		{"ten frames", 10},
+		{"a second", 60},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// LD I, 0x050; DRW V0, V0, 5; then ADD V1, 1 in a loop, which never halts
+			c8, _ := newClockedCore(t, 0xA0, 0x50, 0xD0, 0x05, 0x71, 0x01, 0x12, 0x04)
+			if _, ok := c8.renderer.(nullRenderer); !ok || c8.window != nil {
+				t.Fatalf("headless machine has renderer %T and window %v", c8.renderer, c8.window)
+			}
+
+			renderer := &captureRenderer{}
+			c8.SetRenderer(renderer)
+			c8.SetCyclesPerFrame(10)
+			c8.SetMaxRunTime(framesLimit(tt.frames))
+
+			if err := c8.Run(); err != nil {
+				t.Fatal(err)
+			}
+
+			if renderer.frames != tt.frames {
+				t.Errorf("%d frames presented, want %d", renderer.frames, tt.frames)
+			}
+
+			// The display is kept up to date without a window
+			if c8.GetPixel(0, 0) != DEFAULT_PLANE_COLORS[1] || renderer.colors[0] != DEFAULT_PLANE_COLORS[1] {
+				t.Errorf("pixel 0x%08X, rendered 0x%08X, want both lit", c8.GetPixel(0, 0), renderer.colors[0])
+			}
+		})
+	}
+}
+
+func TestGhosting(t *testing.T) {
+	const background = 0x00000000
+
+	tests := []struct {
+		name  string
+		decay float64
+		fade  []uint32
+	}{
+		{"off", 0, []uint32{background}},
+		{"quarter", 0.25, []uint32{0xFFBFBFBF, 0xFF7F7F7F, 0xFF3F3F3F, background, background}},
+		{"half", 0.5, []uint32{0xFF7F7F7F, background, background}},
+		{"clamped to one frame", 3, []uint32{background, background}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// LD I, 0x050; DRW V0, V0, 5 twice, drawing the font's 0 at (0, 0) and XORing it off again
+			c8 := newTestCore(t, 0xA0, 0x50, 0xD0, 0x05, 0xD0, 0x05)
+			renderer := &captureRenderer{}
+			c8.SetRenderer(renderer)
+			c8.SetGhosting(tt.decay)
+
+			stepN(t, c8, 2)
+			c8.update()
+			if renderer.colors[0] != DEFAULT_PLANE_COLORS[1] {
+				t.Fatalf("pixel rendered 0x%08X after the draw, want it fully lit", renderer.colors[0])
+			}
+
+			// Fades a step each frame once it's turned off
+			stepN(t, c8, 1)
+			for frame, color := range tt.fade {
+				c8.update()
+				if renderer.colors[0] != color {
+					t.Errorf("frame %d after turning off rendered 0x%08X, want 0x%08X", frame+1, renderer.colors[0], color)
+				}
+			}
+		})
+	}
+}
+
+func TestARGBChannels(t *testing.T) {
+	tests := []struct {
+		name       string
+		argb       uint32
+		r, g, b, a uint8
+	}{
+		{"opaque white", 0xFFFFFFFF, 0xFF, 0xFF, 0xFF, 0xFF},
+		{"second plane orange", DEFAULT_PLANE_COLORS[2], 0xFF, 0x66, 0x00, 0xFF},
+		{"distinct channels", 0x80123456, 0x12, 0x34, 0x56, 0x80},
+		{"transparent black", 0x00000000, 0x00, 0x00, 0x00, 0x00},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, g, b, a := argbChannels(tt.argb)
+			if r != tt.r || g != tt.g || b != tt.b || a != tt.a {
+				t.Errorf("channels = %02X %02X %02X %02X, want %02X %02X %02X %02X", r, g, b, a, tt.r, tt.g, tt.b, tt.a)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package emulator
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"log"
+	"os"
+)
+
+// Layout of the file names F12 saves screenshots under, in the working directory
+const SCREENSHOT_NAME_FORMAT = "chip8-20060102-150405.png"
+
+/*
+Saves the current frame as a PNG at the window's video scale, so each display pixel is a videoScale square.
+Colors are the ones the window shows for each plane combination, drawn fully opaque.
+*/
+func (c8 *chip8) Screenshot(path string) error {
+	img := c8.screenshotImage()
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	// A failed close can mean the PNG never fully reached the disk, so it matters as much as an encoding error
+	err = png.Encode(file, img)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// Draws the current frame into an image, holding the state lock so a running ROM can't change it halfway through
+func (c8 *chip8) screenshotImage() *image.RGBA {
+	c8.stateLock.Lock()
+	defer c8.stateLock.Unlock()
+
+	width, height := c8.Resolution()
+	scale := max(c8.videoScale, 1)
+
+	// High-res pixels are half the size of low-res ones on screen
+	if c8.hires {
+		scale = max(scale/2, 1)
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, width*scale, height*scale))
+	for y := range height * scale {
+		for x := range width * scale {
+			argb := c8.pixelColor((y/scale)*width + x/scale)
+			img.Set(x, y, color.RGBA{R: uint8(argb >> 16), G: uint8(argb >> 8), B: uint8(argb), A: 0xFF})
+		}
+	}
+
+	return img
+}
+
+// Saves a screenshot named after the current time and shows where it went
+func (c8 *chip8) saveScreenshot() {
+	path := c8.clock.Now().Format(SCREENSHOT_NAME_FORMAT)
+
+	err := c8.Screenshot(path)
+	if err != nil {
+		log.Println("cannot save screenshot:", err)
+		return
+	}
+
+	c8.showStatus(fmt.Sprintf("saved %s", path))
+}
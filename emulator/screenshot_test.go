@@ -0,0 +1,67 @@
+package emulator
+
+import (
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestScreenshot(t *testing.T) {
+	tests := []struct {
+		name  string
+		hires bool
+		scale int
+	}{
+		{"low-res", false, 10},
+		{"high-res", true, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c8 := newTestCore(t)
+			c8.setHires(tt.hires)
+			c8.plane(0)[0] = 0xFFFFFFFF
+			c8.plane(1)[1] = 0xFFFFFFFF
+			path := filepath.Join(t.TempDir(), "shot.png")
+
+			if err := c8.Screenshot(path); err != nil {
+				t.Fatal(err)
+			}
+
+			file, err := os.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+
+			img, err := png.Decode(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			width, height := c8.Resolution()
+			if size := img.Bounds().Size(); size.X != width*tt.scale || size.Y != height*tt.scale {
+				t.Fatalf("screenshot is %dx%d, want %dx%d", size.X, size.Y, width*tt.scale, height*tt.scale)
+			}
+
+			// First plane, second plane, then nothing lit, in the plane colors made opaque
+			for x, combination := range []int{1, 2, 0} {
+				r, g, b, a := img.At(x*tt.scale+tt.scale-1, tt.scale-1).RGBA()
+				got := (a>>8)<<24 | (r>>8)<<16 | (g>>8)<<8 | b>>8
+
+				if want := DEFAULT_PLANE_COLORS[combination] | 0xFF000000; got != want {
+					t.Errorf("pixel %d = 0x%08X, want 0x%08X", x, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestScreenshotUnwritable(t *testing.T) {
+	c8 := newTestCore(t)
+
+	if err := c8.Screenshot(filepath.Join(t.TempDir(), "missing", "shot.png")); err == nil {
+		t.Error("screenshot into a missing directory didn't fail")
+	}
+}
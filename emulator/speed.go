@@ -0,0 +1,149 @@
+package emulator
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// How often the measured instructions per second are recalculated
+const SPEED_SAMPLE_INTERVAL = time.Second
+
+/*
+Shows the target and measured instructions per second along with the cycle delay, refreshed every second.
+They're drawn over the top-left corner of the display as three rows of digits in the built-in font: target IPS,
+measured IPS, then the cycle delay in milliseconds. The window title repeats them with labels.
+*/
+func (c8 *chip8) SetSpeedOverlay(enabled bool) {
+	c8.speedOverlay = enabled
+
+	if !enabled && c8.statusExpiry.IsZero() && c8.window != nil {
+		c8.window.SetTitle(WINDOW_TITLE)
+	}
+}
+
+// Returns the instructions per second measured over the last sample interval
+func (c8 *chip8) IPS() float64 {
+	return c8.measuredIPS
+}
+
+/*
+Returns the instructions per second Run() aims for. With a cycle delay, Run() cycles once every cycleInterval();
+with cycles per frame, it runs frameCycles() every 60Hz tick. A cycle delay of 0 has no target and returns 0.
+*/
+func (c8 *chip8) TargetIPS() float64 {
+	if c8.cyclesPerFrame > 0 {
+		return float64(c8.frameCycles()) * float64(time.Second/TIMER_INTERVAL)
+	}
+
+	interval := c8.cycleInterval()
+	if interval == 0 {
+		return 0
+	}
+
+	return float64(time.Second) / float64(interval)
+}
+
+// Returns the time between instructions under a cycle delay, shortened or lengthened by the speed multiplier
+func (c8 *chip8) cycleInterval() time.Duration {
+	return time.Duration(c8.cycleDelay * float64(time.Millisecond) / c8.speedMultiplier)
+}
+
+/*
+Runs the ROM this many times faster, e.g. 2 to fast-forward through a slow intro or 0.5 for half speed, within
+MIN_SPEED_MULTIPLIER and MAX_SPEED_MULTIPLIER. Only instructions speed up; the timers stay at 60Hz. With a cycle
+delay, Run() runs any instructions that fell due while it was sleeping or drawing, so even short intervals hold up.
+*/
+func (c8 *chip8) SetSpeedMultiplier(multiplier float64) {
+	c8.speedMultiplier = min(max(multiplier, MIN_SPEED_MULTIPLIER), MAX_SPEED_MULTIPLIER)
+}
+
+// Returns the current speed multiplier, 1 unless changed
+func (c8 *chip8) SpeedMultiplier() float64 {
+	return c8.speedMultiplier
+}
+
+// Sets the keys that double and halve the speed multiplier while running, keypad + and - by default
+func (c8 *chip8) SetSpeedMultiplierKeys(faster sdl.Keycode, slower sdl.Keycode) {
+	c8.speedUpKey = faster
+	c8.speedDownKey = slower
+}
+
+// Scales the speed multiplier by the given factor, keeping it within bounds, and briefly shows the new value
+func (c8 *chip8) adjustSpeedMultiplier(factor float64) {
+	c8.SetSpeedMultiplier(c8.speedMultiplier * factor)
+	c8.showStatus(fmt.Sprintf("speed %gx", c8.speedMultiplier))
+}
+
+// Returns how many instructions each 60Hz frame runs under SetCyclesPerFrame, scaled by the speed multiplier
+func (c8 *chip8) frameCycles() int {
+	return int(math.Round(float64(c8.cyclesPerFrame) * c8.speedMultiplier))
+}
+
+// Recalculates the measured speed once per sample interval and refreshes the readout if it's enabled
+func (c8 *chip8) updateSpeedStats() {
+	if c8.speedSampleStart.IsZero() {
+		c8.speedSampleStart = c8.clock.Now()
+		c8.speedSampleCycles = c8.cycleCount
+		return
+	}
+
+	elapsed := c8.clock.Since(c8.speedSampleStart)
+	if elapsed < SPEED_SAMPLE_INTERVAL {
+		return
+	}
+
+	c8.measuredIPS = float64(c8.cycleCount-c8.speedSampleCycles) / elapsed.Seconds()
+	c8.speedSampleStart = c8.clock.Now()
+	c8.speedSampleCycles = c8.cycleCount
+
+	// Status messages take priority; the readout comes back on the next sample once they expire
+	if c8.speedOverlay && c8.statusExpiry.IsZero() && c8.window != nil {
+		c8.window.SetTitle(c8.speedReadout())
+	}
+}
+
+// Returns the overlay's rows: the target and measured instructions per second, and the cycle delay in milliseconds
+func (c8 *chip8) speedOverlayLines() []string {
+	return []string{
+		fmt.Sprintf("%.0f", c8.TargetIPS()),
+		fmt.Sprintf("%.0f", c8.measuredIPS),
+		fmt.Sprintf("%.0f", c8.cycleDelay),
+	}
+}
+
+/*
+Draws the speed overlay's rows in the viewport's top-left corner, one font pixel in from the edges. Each digit is
+its 4x5 font glyph at SPEED_OVERLAY_SCALE, with a font pixel of space between digits and between rows.
+*/
+func (c8 *chip8) drawSpeedOverlay() {
+	scale := SPEED_OVERLAY_SCALE
+
+	for line, text := range c8.speedOverlayLines() {
+		for pos, char := range text {
+			if char < '0' || char > '9' {
+				continue
+			}
+			glyph := fontset[(char-'0')*5:][:5]
+
+			for row, bits := range glyph {
+				for col := range 4 {
+					if bits&(0x80>>col) == 0 {
+						continue
+					}
+
+					x := c8.viewport.X + scale*int32(1+pos*5+col)
+					y := c8.viewport.Y + scale*int32(1+line*6+row)
+					c8.renderer.Draw(sdl.Rect{X: x, Y: y, W: scale, H: scale}, SPEED_OVERLAY_COLOR)
+				}
+			}
+		}
+	}
+}
+
+// Returns the window title showing the target and measured speeds along with the cycle delay
+func (c8 *chip8) speedReadout() string {
+	return fmt.Sprintf("%s - target %.0f IPS, actual %.0f IPS, cycle delay %.0fms", WINDOW_TITLE, c8.TargetIPS(), c8.measuredIPS, c8.cycleDelay)
+}
@@ -0,0 +1,231 @@
+package emulator
+
+import (
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+func TestSpeedMultiplierPacing(t *testing.T) {
+	tests := []struct {
+		cycleDelay float64
+		multiplier float64
+		want       int
+	}{
+		{5, 1, 200},
+		{5, 2, 400},
+		{5, 0.5, 100},
+		{5, 8, 1600},
+		{1, 8, 8000},
+		{100, 1, 10},
+		{100, 0.5, 5},
+	}
+
+	for _, tt := range tests {
+		// 7001 1200: count up in V0 forever
+		c8, _ := newClockedCore(t, 0x70, 0x01, 0x12, 0x00)
+		c8.cycleDelay = tt.cycleDelay
+		c8.SetSpeedMultiplier(tt.multiplier)
+		c8.SetMaxRunTime(time.Second)
+
+		err := c8.Run()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := int(c8.CycleCount()); got < tt.want-1 || got > tt.want {
+			t.Errorf("%vms delay at %vx: ran %d cycles in a second, want %d", tt.cycleDelay, tt.multiplier, got, tt.want)
+		}
+
+		if got := c8.TargetIPS(); math.Abs(got-float64(tt.want)) > 0.01 {
+			t.Errorf("%vms delay at %vx: target %v IPS, want %d", tt.cycleDelay, tt.multiplier, got, tt.want)
+		}
+	}
+}
+
+func TestSpeedMultiplierFrameBudget(t *testing.T) {
+	tests := []struct {
+		cyclesPerFrame int
+		multiplier     float64
+		want           int
+	}{
+		{600, 1, 600},
+		{600, 2, 1200},
+		{600, 0.5, 300},
+		{10, 0.25, 3},
+		// Clamped to MAX_SPEED_MULTIPLIER and MIN_SPEED_MULTIPLIER
+		{100, 100, 800},
+		{100, 0, 25},
+	}
+
+	for _, tt := range tests {
+		c8, _ := newClockedCore(t, 0x70, 0x01, 0x12, 0x00)
+		c8.SetCyclesPerFrame(tt.cyclesPerFrame)
+		c8.SetSpeedMultiplier(tt.multiplier)
+
+		if got := c8.frameCycles(); got != tt.want {
+			t.Errorf("%d per frame at %vx: budget %d, want %d", tt.cyclesPerFrame, tt.multiplier, got, tt.want)
+		}
+
+		c8.SetMaxRunTime(framesLimit(2))
+
+		err := c8.Run()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := int(c8.CycleCount()); got != 2*tt.want {
+			t.Errorf("%d per frame at %vx: ran %d cycles in 2 frames, want %d", tt.cyclesPerFrame, tt.multiplier, got, 2*tt.want)
+		}
+	}
+}
+
+func TestAdjustSpeedMultiplier(t *testing.T) {
+	c8 := newTestCore(t)
+
+	for _, want := range []float64{2, 4, 8, 8} {
+		c8.adjustSpeedMultiplier(SPEED_MULTIPLIER_STEP)
+		if c8.SpeedMultiplier() != want {
+			t.Fatalf("speeding up gave %vx, want %vx", c8.SpeedMultiplier(), want)
+		}
+	}
+
+	for _, want := range []float64{4, 2, 1, 0.5, 0.25, 0.25} {
+		c8.adjustSpeedMultiplier(1 / SPEED_MULTIPLIER_STEP)
+		if c8.SpeedMultiplier() != want {
+			t.Fatalf("slowing down gave %vx, want %vx", c8.SpeedMultiplier(), want)
+		}
+	}
+}
+
+func TestSpeedReadout(t *testing.T) {
+	tests := []struct {
+		name           string
+		cycleDelay     float64
+		cyclesPerFrame int
+		multiplier     float64
+		want           string
+	}{
+		{"cycle delay", 5, 0, 1, "target 200 IPS, actual 200 IPS, cycle delay 5ms"},
+		{"cycle delay at 2x", 5, 0, 2, "target 400 IPS, actual 400 IPS, cycle delay 5ms"},
+		{"cycles per frame", 0, 10, 1, "target 600 IPS, actual 600 IPS, cycle delay 0ms"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 7001 1200: count up in V0 forever
+			c8, _ := newClockedCore(t, 0x70, 0x01, 0x12, 0x00)
+			c8.cycleDelay = tt.cycleDelay
+			c8.SetCyclesPerFrame(tt.cyclesPerFrame)
+			c8.SetSpeedMultiplier(tt.multiplier)
+			c8.SetSpeedOverlay(true)
+			c8.SetMaxRunTime(3 * time.Second)
+
+			if c8.IPS() != 0 {
+				t.Errorf("IPS() = %v before running", c8.IPS())
+			}
+
+			err := c8.Run()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := c8.speedReadout(); got != WINDOW_TITLE+" - "+tt.want {
+				t.Errorf("readout %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// Reads the overlay's rows back from the rects it drew, matching each 4x5 cell against the font's digits
+func readSpeedOverlay(t *testing.T, rects []sdl.Rect, viewport sdl.Rect) []string {
+	t.Helper()
+	scale := SPEED_OVERLAY_SCALE
+
+	lit := map[[2]int32]bool{}
+	for _, r := range rects {
+		if r.W != scale || r.H != scale {
+			t.Fatalf("overlay rect %v isn't one font pixel", r)
+		}
+		lit[[2]int32{(r.X - viewport.X) / scale, (r.Y - viewport.Y) / scale}] = true
+	}
+
+	lines := []string{}
+	for line := int32(0); ; line++ {
+		text := ""
+		for pos := int32(0); ; pos++ {
+			var cell [5]byte
+			for row := range int32(5) {
+				for col := range int32(4) {
+					if lit[[2]int32{1 + pos*5 + col, 1 + line*6 + row}] {
+						cell[row] |= 0x80 >> col
+					}
+				}
+			}
+			if cell == [5]byte{} {
+				break
+			}
+
+			digit := -1
+			for d := range 10 {
+				if [5]byte(fontset[d*5:]) == cell {
+					digit = d
+				}
+			}
+			if digit < 0 {
+				t.Fatalf("row %d position %d shows % X, which isn't a digit", line, pos, cell)
+			}
+			text += string(rune('0' + digit))
+		}
+
+		if text == "" {
+			return lines
+		}
+		lines = append(lines, text)
+	}
+}
+
+func TestSpeedOverlay(t *testing.T) {
+	tests := []struct {
+		name           string
+		enabled        bool
+		cycleDelay     float64
+		cyclesPerFrame int
+		measured       float64
+		want           []string
+	}{
+		{"cycle delay", true, 5, 0, 187, []string{"200", "187", "5"}},
+		{"cycles per frame", true, 0, 10, 604.4, []string{"600", "604", "0"}},
+		{"before the first sample", true, 2, 0, 0, []string{"500", "0", "2"}},
+		{"disabled", false, 5, 0, 187, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c8 := newTestCore(t)
+			renderer := &captureRenderer{}
+			c8.SetRenderer(renderer)
+			c8.viewport = sdl.Rect{X: 7, Y: 3, W: VIDEO_WIDTH * 10, H: VIDEO_HEIGHT * 10}
+			c8.cycleDelay = tt.cycleDelay
+			c8.SetCyclesPerFrame(tt.cyclesPerFrame)
+			c8.measuredIPS = tt.measured
+			c8.SetSpeedOverlay(tt.enabled)
+
+			c8.update()
+
+			rects := []sdl.Rect{}
+			for k, color := range renderer.colors {
+				if color == SPEED_OVERLAY_COLOR {
+					rects = append(rects, renderer.rects[k])
+				}
+			}
+
+			if got := readSpeedOverlay(t, rects, c8.viewport); !slices.Equal(got, tt.want) {
+				t.Errorf("overlay shows %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package emulator
+
+// Cached sprites are indexed by the pages of memory they cover, so a write only has to check the sprites in its page
+const SPRITE_CACHE_PAGE_SIZE = 64
+
+// Identifies a sprite by where it starts in memory and how many rows it has
+type spriteKey struct {
+	address uint16
+	height  uint16
+}
+
+// Returns the first and last cache pages the sprite's rows fall in
+func (key spriteKey) pages() (int, int) {
+	first := int(key.address) / SPRITE_CACHE_PAGE_SIZE
+	last := (int(key.address) + int(key.height) - 1) / SPRITE_CACHE_PAGE_SIZE
+
+	return first, max(first, last)
+}
+
+// Reports whether the sprite's rows include address
+func (key spriteKey) covers(address int) bool {
+	return address >= int(key.address) && address < int(key.address)+int(key.height)
+}
+
+/*
+Caches sprite rows so games that redraw the same sprite every frame don't re-read memory each time.
+Entries are dropped whenever memory they cover is written, so drawing always reflects the current memory.
+*/
+func (c8 *chip8) SetSpriteCache(enabled bool) {
+	c8.spriteCacheEnabled = enabled
+	c8.spriteCache = make(map[spriteKey][]byte)
+	c8.spritePages = make(map[int]map[spriteKey]bool)
+}
+
+// Returns the given number of sprite bytes at I, going through the cache when it's enabled
+func (c8 *chip8) spriteRows(height uint16) []byte {
+	key := spriteKey{address: c8.indexRegister, height: height}
+
+	if c8.spriteCacheEnabled {
+		if rows, ok := c8.spriteCache[key]; ok {
+			return rows
+		}
+	}
+
+	rows := make([]byte, height)
+	for row := range height {
+		rows[row] = c8.memory[c8.indexRegister+row]
+	}
+
+	if c8.spriteCacheEnabled {
+		c8.cacheSprite(key, rows)
+	}
+
+	return rows
+}
+
+// Adds a sprite to the cache and to the index of every page it covers
+func (c8 *chip8) cacheSprite(key spriteKey, rows []byte) {
+	c8.spriteCache[key] = rows
+
+	first, last := key.pages()
+	for page := first; page <= last; page++ {
+		if c8.spritePages[page] == nil {
+			c8.spritePages[page] = make(map[spriteKey]bool)
+		}
+		c8.spritePages[page][key] = true
+	}
+}
+
+// Drops every cached sprite that covers address, after memory there has been written
+func (c8 *chip8) invalidateSprites(address int) {
+	for key := range c8.spritePages[address/SPRITE_CACHE_PAGE_SIZE] {
+		if !key.covers(address) {
+			continue
+		}
+
+		delete(c8.spriteCache, key)
+
+		first, last := key.pages()
+		for page := first; page <= last; page++ {
+			delete(c8.spritePages[page], key)
+		}
+	}
+}
+
+// Empties the cache, for when memory is replaced wholesale
+func (c8 *chip8) clearSpriteCache() {
+	clear(c8.spriteCache)
+	clear(c8.spritePages)
+}
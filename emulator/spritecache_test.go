@@ -0,0 +1,116 @@
+package emulator
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestSpriteCacheInvalidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		extended bool
+		address  uint16
+		height   uint16
+		write    uint16
+		dropped  bool
+	}{
+		{"write to first row", false, 0x300, 5, 0x300, true},
+		{"write to last row", false, 0x300, 5, 0x304, true},
+		{"write just before", false, 0x300, 5, 0x2FF, false},
+		{"write just after", false, 0x300, 5, 0x305, false},
+		{"sprite across pages, write in the first", false, 0x33E, 4, 0x33F, true},
+		{"sprite across pages, write in the second", false, 0x33E, 4, 0x341, true},
+		{"end of extended memory", true, 0xFFF0, 16, 0xFFFF, true},
+		{"end of extended memory, write before", true, 0xFFF0, 16, 0xFFEF, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c8 := newTestCore(t)
+			c8.SetExtendedMemory(tt.extended)
+			c8.SetSpriteCache(true)
+			for k := range tt.height {
+				c8.memory[tt.address+k] = byte(k + 1)
+			}
+
+			c8.indexRegister = tt.address
+			c8.spriteRows(tt.height)
+			c8.writeMemory(tt.write, 0xAA)
+
+			key := spriteKey{address: tt.address, height: tt.height}
+			if _, cached := c8.spriteCache[key]; cached == tt.dropped {
+				t.Errorf("sprite still cached = %v, want %v", cached, !tt.dropped)
+			}
+
+			first, last := key.pages()
+			for page := first; page <= last; page++ {
+				if c8.spritePages[page][key] == tt.dropped {
+					t.Errorf("page %d indexes the sprite = %v, want %v", page, !tt.dropped, !tt.dropped)
+				}
+			}
+
+			// Whether or not the entry went, the rows read back must match memory
+			want := c8.memory[tt.address : int(tt.address)+int(tt.height)]
+			if got := c8.spriteRows(tt.height); !slices.Equal(got, want) {
+				t.Errorf("rows = % X, memory holds % X", got, want)
+			}
+		})
+	}
+}
+
+func TestSpriteCacheDraw(t *testing.T) {
+	// Draw the sprite at 0x300, overwrite its first row with Fx55, clear and draw it again
+	rom := []byte{0xA3, 0x00, 0xD1, 0x21, 0x00, 0xE0, 0x60, 0x0F, 0xF0, 0x55, 0xA3, 0x00, 0xD1, 0x21}
+
+	for _, cache := range []bool{false, true} {
+		t.Run(fmt.Sprint("cache ", cache), func(t *testing.T) {
+			c8 := newTestCore(t, rom...)
+			c8.SetIncrementIndexOnLoadStore(false)
+			c8.SetSpriteCache(cache)
+			c8.memory[0x300] = 0xF0
+			stepN(t, c8, 7)
+
+			// 0x0F lights the right half of the first byte rather than the left
+			for x := range 8 {
+				if lit, want := c8.pixels[x] != 0, x >= 4; lit != want {
+					t.Errorf("pixel %d lit = %v, want %v", x, lit, want)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkSpriteCache(b *testing.B) {
+	// Draws a sprite and then stores over memory elsewhere, so every loop pays for both a cached draw and a write
+	rom := []byte{0xA3, 0x00, 0xD0, 0x05, 0xA4, 0x00, 0xF3, 0x55, 0x12, 0x00}
+
+	for _, cache := range []bool{false, true} {
+		b.Run(fmt.Sprint("cache ", cache), func(b *testing.B) {
+			c8 := newTestCore(b, rom...)
+			c8.SetSpriteCache(cache)
+
+			for b.Loop() {
+				if err := c8.headlessCycle(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+
+	// Writing shouldn't get slower as more sprites are cached
+	for _, sprites := range []int{1, 100, 1000} {
+		b.Run(fmt.Sprint("write with ", sprites, " cached"), func(b *testing.B) {
+			c8 := newTestCore(b)
+			c8.SetSpriteCache(true)
+			for k := range sprites {
+				c8.indexRegister = uint16(0x300 + k%0xC00)
+				c8.spriteRows(uint16(1 + k/0xC00))
+			}
+
+			for b.Loop() {
+				c8.writeMemory(0x2FF, 0)
+			}
+		})
+	}
+}
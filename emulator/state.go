@@ -0,0 +1,97 @@
+package emulator
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+)
+
+/*
+Serializes the machine state (registers, memory, index register, program counter, stack, stack pointer and timers)
+into a byte slice. SDL resources and settings are not part of the machine state and are left out.
+*/
+func (c8 *chip8) snapshot() []byte {
+	var buf bytes.Buffer
+
+	binary.Write(&buf, binary.BigEndian, c8.registers)
+	binary.Write(&buf, binary.BigEndian, c8.memory)
+	binary.Write(&buf, binary.BigEndian, c8.indexRegister)
+	binary.Write(&buf, binary.BigEndian, c8.programCounter)
+	binary.Write(&buf, binary.BigEndian, c8.stack)
+	binary.Write(&buf, binary.BigEndian, c8.stackPointer)
+	binary.Write(&buf, binary.BigEndian, c8.delayTimer)
+	binary.Write(&buf, binary.BigEndian, c8.soundTimer)
+
+	return buf.Bytes()
+}
+
+// Returns the number of cycles executed since the emulator was created
+func (c8 *chip8) CycleCount() uint64 {
+	return c8.cycleCount
+}
+
+/*
+Requests a snapshot of the machine state once the given number of cycles has been executed.
+Captured snapshots can be compared across runs to pin known-good machine states.
+*/
+func (c8 *chip8) AddCheckpoint(cycle uint64) {
+	if _, ok := c8.checkpoints[cycle]; !ok {
+		c8.checkpoints[cycle] = nil
+	}
+}
+
+// Returns the snapshot captured at the given cycle, or false if it wasn't requested or hasn't been reached yet
+func (c8 *chip8) Checkpoint(cycle uint64) ([]byte, bool) {
+	snapshot := c8.checkpoints[cycle]
+	if snapshot == nil {
+		return nil, false
+	}
+
+	return bytes.Clone(snapshot), true
+}
+
+// Returns a SHA-256 hash of the active display, as rendered from its planes, so frames can be compared without storing the whole pixel buffer
+func (c8 *chip8) DisplayHash() string {
+	c8.stateLock.Lock()
+	defer c8.stateLock.Unlock()
+
+	var buf bytes.Buffer
+	width, height := c8.Resolution()
+	colors := make([]uint32, width*height)
+	for k := range colors {
+		colors[k] = c8.pixelColor(k)
+	}
+	binary.Write(&buf, binary.BigEndian, colors)
+
+	return fmt.Sprintf("%x", sha256.Sum256(buf.Bytes()))
+}
+
+/*
+Returns a SHA-256 hash of the full machine state (registers, memory, PC, I, stack, SP and timers) so full-state
+regressions can be pinned in test fixtures. SDL resources and the random number generator are not included.
+*/
+func (c8 *chip8) StateHash() string {
+	return fmt.Sprintf("%x", sha256.Sum256(c8.snapshot()))
+}
+
+// Returns a copy of V0 through VF
+func (c8 *chip8) Registers() [16]byte {
+	return c8.registers
+}
+
+// Returns a copy of the whole of memory, so changing it doesn't affect the machine
+func (c8 *chip8) Memory() []byte {
+	return bytes.Clone(c8.memory)
+}
+
+// Returns the delay and sound timers
+func (c8 *chip8) Timers() (byte, byte) {
+	return c8.delayTimer, c8.soundTimer
+}
+
+// Returns the CPU state on one line for logging, e.g. "pc=0x0202 i=0x0000 sp=0x00 dt=0x00 st=0x00 v=00 01 ..."
+func (c8 *chip8) DumpState() string {
+	return fmt.Sprintf("pc=0x%04X i=0x%04X sp=0x%02X dt=0x%02X st=0x%02X v=% X",
+		c8.programCounter, c8.indexRegister, c8.stackPointer, c8.delayTimer, c8.soundTimer, c8.registers[:])
+}
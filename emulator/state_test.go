@@ -0,0 +1,158 @@
+package emulator
+
+import (
+	"bytes"
+	"testing"
+)
+
+// ADD V0, 1; JP 0x200: V0 counts loops, so the state changes every other cycle
+var countingROM = []byte{0x70, 0x01, 0x12, 0x00}
+
+func TestCheckpoints(t *testing.T) {
+	tests := []struct {
+		name     string
+		cycle    uint64
+		run      int
+		captured bool
+		v0       byte
+	}{
+		{"first cycle", 1, 10, true, 1},
+		{"later cycle", 7, 10, true, 4},
+		{"last cycle run", 10, 10, true, 5},
+		{"not reached", 11, 10, false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c8 := newTestCore(t, countingROM...)
+			c8.AddCheckpoint(tt.cycle)
+			stepN(t, c8, tt.run)
+
+			snapshot, ok := c8.Checkpoint(tt.cycle)
+			if ok != tt.captured {
+				t.Fatalf("checkpoint captured = %v, want %v", ok, tt.captured)
+			}
+			if !ok {
+				return
+			}
+
+			// V0 is the first byte of a snapshot
+			if snapshot[0] != tt.v0 {
+				t.Errorf("V0 in snapshot = %d, want %d", snapshot[0], tt.v0)
+			}
+
+			// The same ROM run the same way hits the same state
+			again := newTestCore(t, countingROM...)
+			again.AddCheckpoint(tt.cycle)
+			stepN(t, again, tt.run)
+			if other, _ := again.Checkpoint(tt.cycle); !bytes.Equal(snapshot, other) {
+				t.Error("checkpoints differ between identical runs")
+			}
+		})
+	}
+}
+
+func TestCheckpointIsCopied(t *testing.T) {
+	c8 := newTestCore(t, countingROM...)
+	c8.AddCheckpoint(1)
+	stepN(t, c8, 1)
+
+	snapshot, _ := c8.Checkpoint(1)
+	snapshot[0] = 0xFF
+
+	if again, _ := c8.Checkpoint(1); again[0] != 1 {
+		t.Error("changing a returned checkpoint changed the stored one")
+	}
+
+	if _, ok := c8.Checkpoint(2); ok {
+		t.Error("an unrequested cycle has a checkpoint")
+	}
+}
+
+func TestStateHash(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(c8 *chip8)
+		same   bool
+	}{
+		{"nothing", func(c8 *chip8) {}, true},
+		{"register", func(c8 *chip8) { c8.registers[7]++ }, false},
+		{"memory", func(c8 *chip8) { c8.memory[0xFFF]++ }, false},
+		{"index register", func(c8 *chip8) { c8.indexRegister++ }, false},
+		{"program counter", func(c8 *chip8) { c8.programCounter += 2 }, false},
+		{"stack", func(c8 *chip8) { c8.stack[0]++ }, false},
+		{"stack pointer", func(c8 *chip8) { c8.stackPointer++ }, false},
+		{"delay timer", func(c8 *chip8) { c8.delayTimer++ }, false},
+		{"sound timer", func(c8 *chip8) { c8.soundTimer++ }, false},
+		{"display isn't machine state", func(c8 *chip8) { c8.pixels[0] = 0xFFFFFFFF }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newTestCore(t, countingROM...)
+			b := newTestCore(t, countingROM...)
+			stepN(t, a, 5)
+			stepN(t, b, 5)
+
+			tt.change(b)
+
+			if got := a.StateHash() == b.StateHash(); got != tt.same {
+				t.Errorf("hashes equal = %v, want %v", got, tt.same)
+			}
+			if len(a.StateHash()) != 64 {
+				t.Errorf("hash %q isn't 64 hex digits", a.StateHash())
+			}
+		})
+	}
+}
+
+func TestStateAccessors(t *testing.T) {
+	tests := []struct {
+		name         string
+		steps        int
+		v2           byte
+		delay, sound byte
+		dump         string
+	}{
+		{"power on", 0, 0x00, 0, 0, "pc=0x0200 i=0x0000 sp=0x00 dt=0x00 st=0x00 v=00 00 00 00 00 00 00 00 00 00 00 00 00 00 00 00"},
+		{"after loading", 2, 0x2A, 0, 0, "pc=0x0204 i=0x0300 sp=0x00 dt=0x00 st=0x00 v=00 00 2A 00 00 00 00 00 00 00 00 00 00 00 00 00"},
+		{"with timers", 4, 0x2A, 0x2A, 0x2A, "pc=0x0208 i=0x0300 sp=0x00 dt=0x2A st=0x2A v=00 00 2A 00 00 00 00 00 00 00 00 00 00 00 00 00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// LD V2, 0x2A; LD I, 0x300; LD DT, V2; LD ST, V2
+			c8 := newTestCore(t, 0x62, 0x2A, 0xA3, 0x00, 0xF2, 0x15, 0xF2, 0x18)
+			stepN(t, c8, tt.steps)
+
+			if registers := c8.Registers(); registers[2] != tt.v2 {
+				t.Errorf("V2 = 0x%02X, want 0x%02X", registers[2], tt.v2)
+			}
+
+			if delay, sound := c8.Timers(); delay != tt.delay || sound != tt.sound {
+				t.Errorf("timers %d and %d, want %d and %d", delay, sound, tt.delay, tt.sound)
+			}
+
+			if got := c8.DumpState(); got != tt.dump {
+				t.Errorf("DumpState() = %q, want %q", got, tt.dump)
+			}
+		})
+	}
+}
+
+func TestAccessorsAreCopies(t *testing.T) {
+	c8 := newTestCore(t, countingROM...)
+
+	registers := c8.Registers()
+	registers[0] = 0xFF
+	memory := c8.Memory()
+	memory[START_ADDRESS] = 0xFF
+
+	if c8.registers[0] != 0 || c8.memory[START_ADDRESS] != countingROM[0] {
+		t.Error("changing the returned registers or memory changed the machine")
+	}
+
+	if len(memory) != len(c8.memory) {
+		t.Errorf("Memory() returned %d bytes, want %d", len(memory), len(c8.memory))
+	}
+}
@@ -0,0 +1,93 @@
+package emulator
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestAudioCapture(t *testing.T) {
+	tests := []struct {
+		name   string
+		sound  byte
+		cycles int
+	}{
+		{"silent", 0, 200},
+		{"short beep", 5, 200},
+		{"beep past the end", 0xFF, 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// LD V0, sound; LD ST, V0; JP 0x204
+			c8 := newTestCore(t, 0x60, tt.sound, 0xF0, 0x18, 0x12, 0x04)
+
+			var out bytes.Buffer
+			if err := c8.StartAudioCapture(&out); err != nil {
+				t.Fatal(err)
+			}
+
+			for range tt.cycles {
+				if err := c8.headlessCycle(); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := c8.StopAudioCapture(); err != nil {
+				t.Fatal(err)
+			}
+
+			var header wavHeader
+			if err := binary.Read(&out, binary.LittleEndian, &header); err != nil {
+				t.Fatal(err)
+			}
+			if string(header.Riff[:]) != "RIFF" || string(header.Wave[:]) != "WAVE" || string(header.Data[:]) != "data" {
+				t.Fatalf("bad WAV header %+v", header)
+			}
+			if header.SampleRate != AUDIO_SAMPLE_RATE || header.BitsPerSample != 8 || header.Channels != 1 {
+				t.Errorf("format = %dHz %d-bit %d channel, want %dHz 8-bit mono", header.SampleRate, header.BitsPerSample, header.Channels, AUDIO_SAMPLE_RATE)
+			}
+
+			samples := out.Bytes()
+			ticks := tt.cycles / HEADLESS_CYCLES_PER_TICK
+			if int(header.DataSize) != len(samples) || len(samples) != ticks*CAPTURE_SAMPLES_PER_TICK {
+				t.Fatalf("%d samples (header says %d), want %d", len(samples), header.DataSize, ticks*CAPTURE_SAMPLES_PER_TICK)
+			}
+
+			beeping := 0
+			for tick := range ticks {
+				chunk := samples[tick*CAPTURE_SAMPLES_PER_TICK : (tick+1)*CAPTURE_SAMPLES_PER_TICK]
+				if bytes.Count(chunk, []byte{CAPTURE_SILENCE}) != len(chunk) {
+					beeping++
+				}
+			}
+
+			if want := min(int(tt.sound), ticks); beeping != want {
+				t.Errorf("%d ticks have sound, want %d", beeping, want)
+			}
+		})
+	}
+}
+
+func TestAudioCaptureStartStop(t *testing.T) {
+	c8 := newTestCore(t)
+
+	if err := c8.StopAudioCapture(); err == nil {
+		t.Error("stopping without a capture running didn't fail")
+	}
+
+	var out bytes.Buffer
+	if err := c8.StartAudioCapture(&out); err != nil {
+		t.Fatal(err)
+	}
+	if err := c8.StartAudioCapture(&out); err == nil {
+		t.Error("starting a second capture didn't fail")
+	}
+	if err := c8.StopAudioCapture(); err != nil {
+		t.Fatal(err)
+	}
+
+	if out.Len() != 44 {
+		t.Errorf("empty capture is %d bytes, want just the 44 byte header", out.Len())
+	}
+}
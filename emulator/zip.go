@@ -0,0 +1,51 @@
+package emulator
+
+import (
+	"archive/zip"
+	"fmt"
+)
+
+// Lists the names of the files in a zip archive so a ROM can be picked out of a ROM pack
+func ListZipROMs(zipPath string) ([]string, error) {
+	archive, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+
+	names := []string{}
+	for _, f := range archive.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+
+		names = append(names, f.Name)
+	}
+
+	return names, nil
+}
+
+// Loads a single ROM out of a zip archive into memory, the same way LoadChip8ROM does for a plain file
+func (c8 *chip8) LoadChip8ROMFromZip(zipPath string, entryName string) error {
+	archive, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+
+	for _, f := range archive.File {
+		if f.Name != entryName {
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+
+		return c8.LoadROM(rc)
+	}
+
+	return fmt.Errorf("%s not found in %s", entryName, zipPath)
+}
@@ -0,0 +1,86 @@
+package emulator
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// Writes a zip archive holding the given files, plus a directory entry, and returns its path
+func writeZip(t *testing.T, files map[string][]byte) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "roms.zip")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	w := zip.NewWriter(file)
+	if _, err := w.Create("games/"); err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range files {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write(data)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestListZipROMs(t *testing.T) {
+	path := writeZip(t, map[string][]byte{"a.ch8": {0x00, 0xE0}, "games/b.ch8": {0x12, 0x00}})
+
+	names, err := ListZipROMs(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	slices.Sort(names)
+	if want := []string{"a.ch8", "games/b.ch8"}; !slices.Equal(names, want) {
+		t.Errorf("names = %v, want %v", names, want)
+	}
+}
+
+func TestLoadChip8ROMFromZip(t *testing.T) {
+	rom := []byte{0x60, 0x05, 0x12, 0x02}
+	path := writeZip(t, map[string][]byte{"a.ch8": {0x00, 0xE0}, "games/b.ch8": rom})
+
+	tests := []struct {
+		name    string
+		entry   string
+		wantErr bool
+	}{
+		{"entry in a directory", "games/b.ch8", false},
+		{"missing entry", "c.ch8", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c8 := newTestCore(t)
+
+			err := c8.LoadChip8ROMFromZip(path, tt.entry)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadChip8ROMFromZip(%q) error = %v, want error %v", tt.entry, err, tt.wantErr)
+			}
+
+			if tt.entry == "games/b.ch8" && !bytes.Equal(c8.memory[START_ADDRESS:int(START_ADDRESS)+len(rom)], rom) {
+				t.Errorf("memory at 0x%03X = % X, want % X", START_ADDRESS, c8.memory[START_ADDRESS:int(START_ADDRESS)+len(rom)], rom)
+			}
+		})
+	}
+
+	if _, err := ListZipROMs(filepath.Join(t.TempDir(), "missing.zip")); err == nil {
+		t.Error("listing a missing archive didn't fail")
+	}
+}
@@ -3,7 +3,9 @@ package main
 import (
 	"flag"
 	"fmt"
+	"io"
 	"log"
+	"os"
 
 	"github.com/adrichey/go-chip8/emulator"
 )
@@ -12,12 +14,28 @@ var help bool
 var romFile string
 var cycleDelay float64
 var videoScale int
+var resizable bool
+var cyclesPerFrame int
+var speedMultiplier float64
+var conformanceCycles uint64
+var opStatsFile string
+var disasmFile string
+var platformName string
+var compatProfiles bool
 
 func init() {
 	flag.BoolVar(&help, "help", false, "Help")
 	flag.StringVar(&romFile, "f", "", "Path to a Chip8 ROM file")
 	flag.Float64Var(&cycleDelay, "d", 5, "Specifies the cycle delay to control the emulator cycle and update speeds (optional, default 5)")
 	flag.IntVar(&videoScale, "s", 10, "Specifies the video scale for the emulator; Chip8 is 64x32 so 10 == 640x320 (optional, default 10)")
+	flag.IntVar(&cyclesPerFrame, "c", 0, "Runs this many instructions per 60Hz frame instead of one per cycle delay, e.g. 700 (optional, default 0 uses -d)")
+	flag.Float64Var(&speedMultiplier, "speed", 1, "Runs the ROM this many times faster, e.g. 2 or 0.5; keypad + / - double and halve it while running (optional, default 1)")
+	flag.StringVar(&platformName, "platform", "", "Names the platform the ROM was written for, chip8, schip or xochip, which sets the Fx55/Fx65 quirk to match and logs any instruction the ROM uses from a later one (optional)")
+	flag.BoolVar(&compatProfiles, "compat", false, "Applies the built-in compatibility database's recommended platform, quirks and cycles per frame to ROMs it knows, in place of -platform and -c (optional, default false)")
+	flag.BoolVar(&resizable, "r", false, "Makes the window resizable, snapping the display to integer multiples of 64x32 (optional, default false)")
+	flag.StringVar(&opStatsFile, "opstats", "", "Writes per-instruction execution counts as JSON to this file on exit (optional)")
+	flag.StringVar(&disasmFile, "disasm", "", "Writes the ROM's disassembly with addresses and raw opcodes to this file, then exits without running it (optional)")
+	flag.Uint64Var(&conformanceCycles, "conformance", 0, "Runs the ROM for N cycles without a window, prints the display hash and registers, then exits (optional)")
 
 	flag.Parse()
 }
@@ -28,20 +46,100 @@ func main() {
 		return
 	}
 
-	c8, err := emulator.NewChip8(videoScale, cycleDelay)
+	if disasmFile != "" {
+		err := emulator.ExportDisassembly(romFile, disasmFile)
+		if err != nil {
+			log.Fatal("Error disassembling ROM file - ", err)
+		}
+		return
+	}
+
+	// Everything that can fail is in run(), so its deferred cleanup has happened by the time we exit
+	err := run()
 	if err != nil {
 		log.Fatal(err)
-		return
 	}
+}
 
-	err = c8.LoadChip8ROM(romFile)
+func run() (err error) {
+	platform, err := emulator.ParsePlatform(platformName)
 	if err != nil {
-		log.Fatal("Error loading ROM file - ", err)
-		return
+		return err
+	}
+
+	options := []emulator.Option{}
+	if conformanceCycles > 0 {
+		options = append(options, emulator.Headless())
+	}
+
+	c8, err := emulator.NewChip8(videoScale, cycleDelay, options...)
+	if err != nil {
+		return err
 	}
 	defer c8.Destroy()
 
-	c8.Run()
+	// Set before loading, so a compatibility profile applied at load can replace them
+	c8.SetPlatform(platform)
+	c8.SetCyclesPerFrame(cyclesPerFrame)
+	c8.SetCompatibilityProfiles(compatProfiles)
+
+	err = c8.LoadChip8ROM(romFile)
+	if err != nil {
+		return fmt.Errorf("Error loading ROM file - %w", err)
+	}
+
+	// Written however the run ends, so a ROM that crashes still leaves the counts leading up to the crash
+	defer func() {
+		statsErr := writeOpStats(c8.WriteOpcodeStats)
+		if err == nil {
+			err = statsErr
+		}
+	}()
+
+	if conformanceCycles > 0 {
+		report, err := c8.RunConformance(conformanceCycles)
+		if err != nil {
+			return fmt.Errorf("Error running ROM - %w", err)
+		}
+
+		fmt.Print(report)
+		return nil
+	}
+
+	if resizable {
+		c8.SetIntegerScaling(true)
+	}
+
+	c8.SetSpeedMultiplier(speedMultiplier)
+
+	err = c8.Run()
+	if err != nil {
+		return fmt.Errorf("Error running ROM - %w", err)
+	}
+
+	return nil
+}
+
+// Writes the opcode stats with write to the -opstats file, if one was given
+func writeOpStats(write func(w io.Writer) error) error {
+	if opStatsFile == "" {
+		return nil
+	}
+
+	file, err := os.Create(opStatsFile)
+	if err != nil {
+		return fmt.Errorf("Error writing opcode stats - %w", err)
+	}
+
+	err = write(file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("Error writing opcode stats - %w", err)
+	}
+
+	return nil
 }
 
 func displayHelp() {
@@ -49,6 +147,14 @@ func displayHelp() {
 	fmt.Println("-f: Path to a Chip8 ROM file")
 	fmt.Println("-d: Specifies the cycle delay to control the emulator cycle and update speeds (optional, default 5)")
 	fmt.Println("-s: Specifies the video scale for the emulator; Chip8 is 64x32 so 10 == 640x320 (optional, default 10)")
+	fmt.Println("-c: Runs this many instructions per 60Hz frame instead of one per cycle delay, e.g. 700 (optional, default 0 uses -d)")
+	fmt.Println("-speed: Runs the ROM this many times faster, e.g. 2 or 0.5; keypad + / - double and halve it while running (optional, default 1)")
+	fmt.Println("-platform: Names the platform the ROM was written for, chip8, schip or xochip, which sets the Fx55/Fx65 quirk to match and logs any instruction the ROM uses from a later one (optional)")
+	fmt.Println("-compat: Applies the built-in compatibility database's recommended platform, quirks and cycles per frame to ROMs it knows, in place of -platform and -c (optional, default false)")
+	fmt.Println("-r: Makes the window resizable, snapping the display to integer multiples of 64x32 (optional, default false)")
+	fmt.Println("-opstats: Writes per-instruction execution counts as JSON to this file on exit (optional)")
+	fmt.Println("-disasm: Writes the ROM's disassembly with addresses and raw opcodes to this file, then exits without running it (optional)")
+	fmt.Println("-conformance: Runs the ROM for N cycles without a window, prints the display hash and registers, then exits (optional)")
 	fmt.Println()
 	fmt.Println("Example:")
 	fmt.Println("./go-chip8 -f ./roms/1-chip8-logo.ch8")